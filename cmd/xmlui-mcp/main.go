@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"xmlui-mcp/pkg/xmluimcp"
+	mcpserver "xmlui-mcp/server"
 )
 
 // stringSlice handles repeated string flags
@@ -25,10 +26,12 @@ func (s *stringSlice) Set(value string) error {
 func main() {
 	// Define command-line flags
 	var (
-		httpMode     = flag.Bool("http", false, "Run in HTTP mode instead of stdio")
-		port         = flag.String("port", "8080", "Port to listen on in HTTP mode")
-		xmluiVersion = flag.String("xmlui-version", "", "Specific XMLUI version to use (e.g. 0.11.4)")
-		exampleDirs  stringSlice
+		httpMode      = flag.Bool("http", false, "Run in HTTP mode instead of stdio")
+		port          = flag.String("port", "8080", "Port to listen on in HTTP mode")
+		xmluiVersion  = flag.String("xmlui-version", "", "Specific XMLUI version to use (e.g. 0.11.4)")
+		searchTimeout = flag.Duration("search-timeout", 0, "Time budget per search call before partial results are returned (default 20s)")
+		scorerName    = flag.String("scorer", "heuristic", "Ranking used by the search tools: "+strings.Join(mcpserver.ScorerNames, " or "))
+		exampleDirs   stringSlice
 	)
 
 	// Bind example flag and its alias
@@ -38,6 +41,12 @@ func main() {
 	// Parse flags
 	flag.Parse()
 
+	scorer, err := mcpserver.ScorerByName(*scorerName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Create server configuration
 	// The XMLUI repository will be automatically downloaded and cached by NewServer
 	config := xmluimcp.ServerConfig{
@@ -45,6 +54,9 @@ func main() {
 		HTTPMode:     *httpMode,
 		Port:         *port,
 		XMLUIVersion: *xmluiVersion,
+
+		SearchTimeout: *searchTimeout,
+		Scorer:        scorer,
 	}
 
 	// Create and start the server
@@ -25,6 +25,14 @@ type ServerConfig struct {
 	Port         string   // Port for HTTP mode (default: "8080")
 	XMLUIVersion string   // Specific XMLUI version to use (e.g. "0.11.4")
 	CLIVersion   string   // Version of the xmlui CLI (set via ldflags)
+
+	// SearchTimeout bounds each search tool call; when it runs out the call
+	// returns partial results marked truncated. Zero uses the default.
+	SearchTimeout time.Duration
+
+	// Scorer ranks the search tools' results. Nil uses the default
+	// heuristic; mcpserver.ScorerByName resolves the shipped ones by name.
+	Scorer mcpserver.Scorer
 }
 
 // MCPServer represents an XMLUI MCP server instance
@@ -70,6 +78,24 @@ func NewServer(config ServerConfig) (*MCPServer, error) {
 	mcpserver.WriteDebugLog("Using cached XMLUI repository at: %s\n", cachedRepo)
 	mcpserver.SetCorpusStamp(corpusStamp(cachedRepo))
 
+	// Search indexes persist beside the analytics file, filed per corpus tag.
+	// The cached repo is a released snapshot, so its roots index exactly once.
+	if cacheDir != "" {
+		mcpserver.SetSearchIndexDir(filepath.Join(cacheDir, "search-index"))
+	}
+	mcpserver.SetSearchIndexCorpus(cachedRepo)
+	// Stopwords and synonyms: built-in defaults, then the corpus's
+	// mcp-search.{json,yaml}, then the user's copy in their config directory.
+	userConfigDir := ""
+	if dir, err := os.UserConfigDir(); err == nil {
+		userConfigDir = filepath.Join(dir, "xmlui", "xmlui-mcp")
+	}
+	mcpserver.SetSearchDictionaryDirs(cachedRepo, userConfigDir)
+	if config.SearchTimeout > 0 {
+		mcpserver.SetSearchTimeout(config.SearchTimeout)
+	}
+	mcpserver.SetSearchScorer(config.Scorer)
+
 	// Set defaults
 	if config.Port == "" {
 		config.Port = "8080"
@@ -86,6 +112,7 @@ func NewServer(config ServerConfig) (*MCPServer, error) {
 
 	serverOptions := []server.ServerOption{
 		server.WithPromptCapabilities(true),
+		server.WithResourceCapabilities(false, false),
 	}
 	if updateNotice != "" {
 		serverOptions = append(serverOptions, server.WithInstructions(updateNotice))
@@ -177,6 +204,26 @@ func (s *MCPServer) setupTools() error {
 	s.mcpServer.AddTool(componentDocsTool, mcpserver.WithAnalytics("xmlui_component_docs", componentDocsHandler))
 	s.tools = append(s.tools, componentDocsTool)
 
+	// Component schema tool
+	componentSchemaTool, componentSchemaHandler := mcpserver.NewComponentSchemaTool(s.xmluiDir)
+	s.mcpServer.AddTool(componentSchemaTool, mcpserver.WithAnalytics("xmlui_component_schema", componentSchemaHandler))
+	s.tools = append(s.tools, componentSchemaTool)
+
+	// Compare components tool
+	compareComponentsTool, compareComponentsHandler := mcpserver.NewCompareComponentsTool(s.xmluiDir)
+	s.mcpServer.AddTool(compareComponentsTool, mcpserver.WithAnalytics("xmlui_compare_components", compareComponentsHandler))
+	s.tools = append(s.tools, compareComponentsTool)
+
+	// Theme variables tool
+	themeVarsTool, themeVarsHandler := mcpserver.NewThemeVarsTool(s.xmluiDir)
+	s.mcpServer.AddTool(themeVarsTool, mcpserver.WithAnalytics("xmlui_theme_vars", themeVarsHandler))
+	s.tools = append(s.tools, themeVarsTool)
+
+	// Deprecations tool
+	deprecationsTool, deprecationsHandler := mcpserver.NewDeprecationsTool(s.xmluiDir)
+	s.mcpServer.AddTool(deprecationsTool, mcpserver.WithAnalytics("xmlui_deprecations", deprecationsHandler))
+	s.tools = append(s.tools, deprecationsTool)
+
 	// Search docs tool
 	searchDocsTool, searchDocsHandler := mcpserver.NewSearchTool(s.xmluiDir, exampleRoots)
 	s.mcpServer.AddTool(searchDocsTool, mcpserver.WithSearchAnalytics("xmlui_search", searchDocsHandler))
@@ -187,11 +234,26 @@ func (s *MCPServer) setupTools() error {
 	s.mcpServer.AddTool(readFileTool, mcpserver.WithAnalytics("xmlui_read_file", readFileHandler))
 	s.tools = append(s.tools, readFileTool)
 
+	// Similar pages tool
+	similarTool, similarHandler := mcpserver.NewSimilarTool(s.xmluiDir)
+	s.mcpServer.AddTool(similarTool, mcpserver.WithAnalytics("xmlui_similar", similarHandler))
+	s.tools = append(s.tools, similarTool)
+
+	// Find symbol tool
+	findSymbolTool, findSymbolHandler := mcpserver.NewFindSymbolTool(s.xmluiDir)
+	s.mcpServer.AddTool(findSymbolTool, mcpserver.WithAnalytics("xmlui_find_symbol", findSymbolHandler))
+	s.tools = append(s.tools, findSymbolTool)
+
 	// Examples tool
 	examplesTool, examplesHandler := mcpserver.NewExamplesTool(exampleRoots)
 	s.mcpServer.AddTool(examplesTool, mcpserver.WithSearchAnalytics("xmlui_examples", examplesHandler))
 	s.tools = append(s.tools, examplesTool)
 
+	// Validate markup tool
+	validateMarkupTool, validateMarkupHandler := mcpserver.NewValidateMarkupTool(s.xmluiDir, exampleRoots)
+	s.mcpServer.AddTool(validateMarkupTool, mcpserver.WithAnalytics("xmlui_validate_markup", validateMarkupHandler))
+	s.tools = append(s.tools, validateMarkupTool)
+
 	// Find trace tool
 	findTraceTool, findTraceHandler := mcpserver.NewFindTraceTool()
 	s.mcpServer.AddTool(findTraceTool, mcpserver.WithAnalytics("xmlui_find_trace", findTraceHandler))
@@ -432,6 +494,11 @@ func (s *MCPServer) setupTools() error {
 	s.mcpServer.AddTool(statusTool, mcpserver.WithAnalytics("xmlui_status", statusHandler))
 	s.tools = append(s.tools, statusTool)
 
+	// The search tools' format=json output has no Tool.outputSchema slot in
+	// this mcp-go release, so its schema is served as a resource instead.
+	mediatorSchema, mediatorSchemaHandler := mcpserver.NewMediatorSchemaResource()
+	s.mcpServer.AddResource(mediatorSchema, mediatorSchemaHandler)
+
 	return nil
 }
 
@@ -313,17 +313,20 @@ func toolResultMetrics(result *mcp.CallToolResult, err error) (bool, int, string
 
 // ExecuteMediatedSearchWithAnalytics keeps presentation and retrieval metrics
 // separate: the human string is returned to the caller, while the structured
-// mediator summary is the sole source of search-quality analytics.
+// mediator summary is the sole source of search-quality analytics. The
+// summary is returned too, for tools rendering the JSON output formats.
+// Results come through the query cache; a cache hit is still observed, so
+// analytics count what agents asked, not what the mediator recomputed.
 func ExecuteMediatedSearchWithAnalytics(
 	ctx context.Context,
 	toolName string,
 	homeDir string,
 	cfg MediatorConfig,
 	query string,
-) (string, error) {
-	human, summary, err := ExecuteMediatedSearch(homeDir, cfg, query)
+) (string, MediatorJSON, error) {
+	human, summary, err := cachedMediatedSearch(ctx, homeDir, cfg, query)
 	recordSearchObservation(ctx, toolName, query, err == nil, summary, corpusVersionForDir(homeDir))
-	return human, err
+	return human, summary, err
 }
 
 func corpusVersionForDir(homeDir string) string {
@@ -692,14 +695,22 @@ func prependUpdateNotice(result *mcp.CallToolResult) {
 	}
 
 	for i, content := range result.Content {
+		var text string
 		switch tc := content.(type) {
 		case *mcp.TextContent:
-			result.Content[i] = mcp.NewTextContent(globalUpdateNotice + "\n\n" + tc.Text)
-			return
+			text = tc.Text
 		case mcp.TextContent:
-			result.Content[i] = mcp.NewTextContent(globalUpdateNotice + "\n\n" + tc.Text)
+			text = tc.Text
+		default:
+			continue
+		}
+		// A JSON item must stay decodable: the notice rides as its own item.
+		if isJSONText(text) {
+			result.Content = append([]mcp.Content{mcp.NewTextContent(globalUpdateNotice)}, result.Content...)
 			return
 		}
+		result.Content[i] = mcp.NewTextContent(globalUpdateNotice + "\n\n" + text)
+		return
 	}
 	// No text content to carry the notice: don't consume this slot.
 	updateNoticeCallCount--
@@ -716,6 +727,9 @@ var (
 func SetCorpusStamp(stamp string) {
 	globalCorpusStampMu.Lock()
 	defer globalCorpusStampMu.Unlock()
+	if stamp != globalCorpusStamp {
+		queryCache.Purge()
+	}
 	globalCorpusStamp = stamp
 }
 
@@ -734,21 +748,25 @@ func appendCorpusStamp(result *mcp.CallToolResult) {
 	}
 
 	for i := len(result.Content) - 1; i >= 0; i-- {
-		content := result.Content[i]
-		switch tc := content.(type) {
+		var text string
+		switch tc := result.Content[i].(type) {
 		case *mcp.TextContent:
-			if strings.HasSuffix(tc.Text, stamp) {
-				return
-			}
-			result.Content[i] = mcp.NewTextContent(tc.Text + "\n\n" + stamp)
-			return
+			text = tc.Text
 		case mcp.TextContent:
-			if strings.HasSuffix(tc.Text, stamp) {
-				return
-			}
-			result.Content[i] = mcp.NewTextContent(tc.Text + "\n\n" + stamp)
+			text = tc.Text
+		default:
+			continue
+		}
+		if strings.HasSuffix(text, stamp) {
 			return
 		}
+		// A JSON item must stay decodable: the stamp rides as its own item.
+		if isJSONText(text) {
+			result.Content = append(result.Content, mcp.NewTextContent(stamp))
+			return
+		}
+		result.Content[i] = mcp.NewTextContent(text + "\n\n" + stamp)
+		return
 	}
 }
 
@@ -777,8 +795,16 @@ func logSearchV2(invocationID string, toolName string, query string, executionSu
 }
 
 func GetAnalyticsSummary() map[string]interface{} {
+	summary := map[string]interface{}{}
 	if globalAnalytics != nil {
-		return globalAnalytics.GetSummary()
-	}
-	return map[string]interface{}{}
+		summary = globalAnalytics.GetSummary()
+	}
+	// The query cache lives for the process, not the analytics log, so its
+	// counters cover this session only.
+	hits, misses, entries := queryCache.stats()
+	summary["search_cache_hits"] = hits
+	summary["search_cache_misses"] = misses
+	summary["search_cache_entries"] = entries
+	summary["search_cache_hit_rate"] = percentage(hits, hits+misses)
+	return summary
 }
@@ -0,0 +1,282 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxCompareComponents bounds one comparison; beyond a handful the
+// per-component columns stop being readable.
+const maxCompareComponents = 5
+
+// comparedComponent is one side of a comparison: its page's schema with the
+// members of the component it derives from merged in, as
+// markupVocabulary.members merges them for the validator, so a variant with
+// a thin page (VStack, NumberBox) compares by what it actually accepts.
+type comparedComponent struct {
+	*ComponentSchema // a copy, holding the merged member lists
+	parent           string
+	inherited        map[string]bool // "kind/name" of members only the parent's page lists
+}
+
+// newComparedComponent merges parent's members into a copy of schema.
+// parent may be nil.
+func newComparedComponent(schema, parent *ComponentSchema) *comparedComponent {
+	merged := *schema
+	c := &comparedComponent{ComponentSchema: &merged, inherited: make(map[string]bool)}
+	if parent == nil {
+		return c
+	}
+	c.parent = parent.Component
+	inherit := func(kind string, own, theirs []SchemaMember) []SchemaMember {
+		out := append([]SchemaMember(nil), own...)
+		for _, m := range theirs {
+			if !hasSchemaMember(own, m.Name) {
+				out = append(out, m)
+				c.inherited[kind+"/"+m.Name] = true
+			}
+		}
+		return out
+	}
+	merged.Props = inherit("props", schema.Props, parent.Props)
+	merged.Events = inherit("events", schema.Events, parent.Events)
+	merged.Methods = inherit("methods", schema.Methods, parent.Methods)
+	return c
+}
+
+func hasSchemaMember(members []SchemaMember, name string) bool {
+	for _, m := range members {
+		if m.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// inheritedFrom returns the component c inherits the member from, or "".
+func (c *comparedComponent) inheritedFrom(kind, name string) string {
+	if c.inherited[kind+"/"+name] {
+		return c.parent
+	}
+	return ""
+}
+
+// memberComparison sorts one kind of member across the compared
+// components: those all of them have, those only one has, and the rest
+// with the components that have them. Inherited members are labeled with
+// the component whose page lists them.
+type memberComparison struct {
+	Shared  []string
+	Only    map[string][]string // component → members only it has
+	Partial []string            // "name (A, B from C)"
+}
+
+// compareMembers compares one kind of member of components, in member
+// order of the first component that has each.
+func compareMembers(components []*comparedComponent, kind string, members func(*ComponentSchema) []SchemaMember) memberComparison {
+	cmp := memberComparison{Only: make(map[string][]string)}
+	owners := make(map[string][]*comparedComponent)
+	var order []string
+	for _, c := range components {
+		for _, m := range members(c.ComponentSchema) {
+			if len(owners[m.Name]) == 0 {
+				order = append(order, m.Name)
+			}
+			owners[m.Name] = append(owners[m.Name], c)
+		}
+	}
+	for _, name := range order {
+		switch have := owners[name]; {
+		case len(have) == len(components):
+			var from []string
+			for _, c := range have {
+				if parent := c.inheritedFrom(kind, name); parent != "" && !containsString(from, parent) {
+					from = append(from, parent)
+				}
+			}
+			if len(from) > 0 {
+				name += " (from " + strings.Join(from, ", ") + ")"
+			}
+			cmp.Shared = append(cmp.Shared, name)
+		case len(have) == 1:
+			if parent := have[0].inheritedFrom(kind, name); parent != "" {
+				name += " (from " + parent + ")"
+			}
+			cmp.Only[have[0].Component] = append(cmp.Only[have[0].Component], name)
+		default:
+			labels := make([]string, len(have))
+			for i, c := range have {
+				labels[i] = c.Component
+				if parent := c.inheritedFrom(kind, name); parent != "" {
+					labels[i] += " from " + parent
+				}
+			}
+			cmp.Partial = append(cmp.Partial, fmt.Sprintf("%s (%s)", name, strings.Join(labels, ", ")))
+		}
+	}
+	return cmp
+}
+
+// differingDefaults lists the props every component has whose defaults
+// differ, as "size: Select "sm", AutoComplete "md"".
+func differingDefaults(components []*comparedComponent) []string {
+	var out []string
+	for _, prop := range components[0].Props {
+		var parts []string
+		distinct := make(map[string]bool)
+		for _, c := range components {
+			def, found := "", false
+			for _, m := range c.Props {
+				if m.Name == prop.Name {
+					def, found = m.Default, true
+					break
+				}
+			}
+			if !found {
+				parts = nil
+				break
+			}
+			distinct[def] = true
+			if def == "" {
+				def = "(none)"
+			}
+			parts = append(parts, c.Component+" "+def)
+		}
+		if len(distinct) > 1 && parts != nil {
+			out = append(out, prop.Name+": "+strings.Join(parts, ", "))
+		}
+	}
+	return out
+}
+
+func NewCompareComponentsTool(homeDir string) (mcp.Tool, func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)) {
+	tool := mcp.NewTool("xmlui_compare_components",
+		mcp.WithDescription("Compares two or more XMLUI components side by side from their reference pages: overview summaries, shared props and props unique to each (with differing defaults), and the same for events and exposed methods. A variant such as VStack includes the members of the component it derives from, labeled '(from Stack)'. Use it for \"Stack vs FlowLayout\" or \"Select vs AutoComplete\" questions instead of fetching each page."),
+		mcp.WithArray("components",
+			mcp.Required(),
+			mcp.Items(map[string]any{"type": "string"}),
+			mcp.Description(fmt.Sprintf("2 to %d component names, e.g. [\"Select\", \"AutoComplete\"]. A comma-separated string is accepted too.", maxCompareComponents)),
+		),
+	)
+
+	tool.Annotations = mcp.ToolAnnotation{
+		ReadOnlyHint:    true,
+		DestructiveHint: false,
+		IdempotentHint:  true,
+		OpenWorldHint:   false,
+	}
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		raw, err := stringListArgument(req, "components")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		var names []string
+		for _, name := range raw {
+			if name = normalizeComponentArg(name); name != "" && !containsString(names, name) {
+				names = append(names, name)
+			}
+		}
+		if len(names) > maxCompareComponents {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid 'components' parameter: pass 2 to %d distinct component names", maxCompareComponents)), nil
+		}
+
+		// Names are de-duplicated again once resolved: "Button" and
+		// "button" are one component.
+		families := componentFamiliesFor(ctx, homeDir)
+		var components []*comparedComponent
+		seen := make(map[string]bool)
+		for _, name := range names {
+			schema, ok := componentSchemaFor(ctx, homeDir, name)
+			if ctx.Err() != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Comparison %s before the component docs were indexed", searchTruncation(ctx))), nil
+			}
+			if !ok {
+				return mcp.NewToolResultError(componentNotFoundMessage(homeDir, GetRepoPaths(homeDir), name)), nil
+			}
+			if seen[schema.Component] {
+				continue
+			}
+			seen[schema.Component] = true
+			var parent *ComponentSchema
+			if name := families.parent(schema.Component); name != "" {
+				parent, _ = componentSchemaFor(ctx, homeDir, name)
+			}
+			components = append(components, newComparedComponent(schema, parent))
+		}
+		if len(components) < 2 {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid 'components' parameter: pass 2 to %d distinct component names", maxCompareComponents)), nil
+		}
+		return mcp.NewToolResultText(formatComparison(components)), nil
+	}
+
+	return tool, handler
+}
+
+func formatComparison(components []*comparedComponent) string {
+	var out strings.Builder
+	names := make([]string, len(components))
+	for i, c := range components {
+		names[i] = c.Component
+	}
+	fmt.Fprintf(&out, "Comparing %s\n", strings.Join(names, " vs "))
+
+	out.WriteString("\n## Overview\n")
+	for _, c := range components {
+		name := c.Component
+		if c.parent != "" {
+			name += " ← " + c.parent
+		}
+		summary := c.Summary
+		if summary == "" {
+			summary = "(no overview)"
+		}
+		if c.Deprecated {
+			summary = "**DEPRECATED** " + summary
+		}
+		fmt.Fprintf(&out, "- %s: %s\n  URL: %s\n", name, summary, c.URL)
+	}
+
+	props := compareMembers(components, "props", func(s *ComponentSchema) []SchemaMember { return s.Props })
+	writeMemberComparison(&out, "Props", components, props, func(s *ComponentSchema) int { return len(s.Props) })
+	if diffs := differingDefaults(components); len(diffs) > 0 {
+		out.WriteString("Differing defaults:\n")
+		for _, d := range diffs {
+			fmt.Fprintf(&out, "  %s\n", d)
+		}
+	}
+	events := compareMembers(components, "events", func(s *ComponentSchema) []SchemaMember { return s.Events })
+	writeMemberComparison(&out, "Events", components, events, func(s *ComponentSchema) int { return len(s.Events) })
+	methods := compareMembers(components, "methods", func(s *ComponentSchema) []SchemaMember { return s.Methods })
+	writeMemberComparison(&out, "Exposed methods", components, methods, func(s *ComponentSchema) int { return len(s.Methods) })
+	return out.String()
+}
+
+func writeMemberComparison(out *strings.Builder, title string, components []*comparedComponent, cmp memberComparison, count func(*ComponentSchema) int) {
+	fmt.Fprintf(out, "\n## %s\n", title)
+	list := func(members []string) string {
+		if len(members) == 0 {
+			return "(none)"
+		}
+		return strings.Join(members, ", ")
+	}
+	fmt.Fprintf(out, "Shared (%d): %s\n", len(cmp.Shared), list(cmp.Shared))
+	for _, c := range components {
+		if count(c.ComponentSchema) == 0 {
+			if c.parent != "" {
+				fmt.Fprintf(out, "Only %s: (neither its page nor %s's lists any)\n", c.Component, c.parent)
+			} else {
+				fmt.Fprintf(out, "Only %s: (its page lists none)\n", c.Component)
+			}
+			continue
+		}
+		only := cmp.Only[c.Component]
+		fmt.Fprintf(out, "Only %s (%d): %s\n", c.Component, len(only), list(only))
+	}
+	if len(cmp.Partial) > 0 {
+		fmt.Fprintf(out, "Some (%d): %s\n", len(cmp.Partial), strings.Join(cmp.Partial, "; "))
+	}
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const compareSelectDoc = `# Select [#select]
+
+> [!INFO]
+> Supports keyboard navigation.
+
+Select picks one value from a list.
+It renders a dropdown.
+
+## Properties [#properties]
+
+### ` + "`placeholder`" + `
+
+> [!DEF]  default: **"Select..."**
+
+### ` + "`searchable`" + `
+
+### ` + "`multiSelect`" + `
+
+## Events [#events]
+
+### ` + "`didChange`" + `
+`
+
+const compareAutoCompleteDoc = `# AutoComplete [#autocomplete]
+
+AutoComplete suggests values as you type.
+
+## Properties [#properties]
+
+### ` + "`placeholder`" + `
+
+> [!DEF]  default: **""**
+
+### ` + "`multi`" + `
+
+### ` + "`multiSelect`" + `
+
+## Events [#events]
+
+### ` + "`didChange`" + `
+
+### ` + "`gotFocus`" + `
+`
+
+func TestCompareComponentsTool(t *testing.T) {
+	ResetRepoPaths()
+	t.Cleanup(ResetRepoPaths)
+	root := t.TempDir()
+	componentsDir := filepath.Join(root, "docs", "content", "components")
+	if err := os.MkdirAll(componentsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, body := range map[string]string{"Select.md": compareSelectDoc, "AutoComplete.md": compareAutoCompleteDoc} {
+		if err := os.WriteFile(filepath.Join(componentsDir, name), []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, handler := NewCompareComponentsTool(root)
+
+	var req mcp.CallToolRequest
+	req.Params.Arguments = map[string]interface{}{"components": []interface{}{"Select", "autocomplete"}}
+	result, err := handler(context.Background(), req)
+	if err != nil || result.IsError {
+		t.Fatalf("unexpected error: %v %+v", err, result)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	for _, want := range []string{
+		"Comparing Select vs AutoComplete\n",
+		"- Select: Select picks one value from a list. It renders a dropdown.\n",
+		"- AutoComplete: AutoComplete suggests values as you type.\n",
+		"## Props\nShared (2): placeholder, multiSelect\nOnly Select (1): searchable\nOnly AutoComplete (1): multi\n",
+		"Differing defaults:\n  placeholder: Select \"Select...\", AutoComplete \"\"\n",
+		"## Events\nShared (1): didChange\nOnly Select (0): (none)\nOnly AutoComplete (1): gotFocus\n",
+		"## Exposed methods\nShared (0): (none)\nOnly Select: (its page lists none)\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("missing %q in:\n%s", want, text)
+		}
+	}
+
+	req.Params.Arguments = map[string]interface{}{"components": "Select, Select"}
+	if result, _ := handler(context.Background(), req); !result.IsError {
+		t.Fatal("a single distinct component was accepted")
+	}
+	req.Params.Arguments = map[string]interface{}{"components": "Select, Slect"}
+	if result, _ := handler(context.Background(), req); !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, `"Slect" not found`) {
+		t.Fatalf("missing component: %+v", result)
+	}
+}
+
+// Variants compare with their parent's members merged in, and names that
+// resolve to one component count once.
+func TestCompareComponentsMergesFamilies(t *testing.T) {
+	ResetRepoPaths()
+	t.Cleanup(ResetRepoPaths)
+	root := t.TempDir()
+	files := map[string]string{
+		"docs/content/components/Stack.md":  "# Stack\n\n## Properties\n\n### `orientation`\n\n### `gap`\n",
+		"docs/content/components/VStack.md": "# VStack\n\nA vertical Stack.\n",
+		"docs/content/components/HStack.md": "# HStack\n\n## Properties\n\n### `reverse`\n",
+		"xmlui/src/components/Stack/Stack.tsx": `export const StackMd = createMetadata({ props: {} });
+export const VStackMd = createMetadata({ ...StackMd });
+export const HStackMd = createMetadata({ ...StackMd });
+`,
+	}
+	for rel, body := range files {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, handler := NewCompareComponentsTool(root)
+
+	var req mcp.CallToolRequest
+	req.Params.Arguments = map[string]interface{}{"components": []interface{}{"VStack", "vstack", "HStack"}}
+	result, err := handler(context.Background(), req)
+	if err != nil || result.IsError {
+		t.Fatalf("unexpected error: %v %+v", err, result)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	for _, want := range []string{
+		"Comparing VStack vs HStack\n",
+		"- VStack ← Stack: A vertical Stack.\n",
+		"## Props\nShared (2): orientation (from Stack), gap (from Stack)\nOnly VStack (0): (none)\nOnly HStack (1): reverse\n",
+		"## Events\nShared (0): (none)\nOnly VStack: (neither its page nor Stack's lists any)\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("missing %q in:\n%s", want, text)
+		}
+	}
+
+	req.Params.Arguments = map[string]interface{}{"components": []interface{}{"Stack", "stack"}}
+	if result, _ := handler(context.Background(), req); !result.IsError {
+		t.Fatal("two spellings of one component were compared")
+	}
+}
@@ -13,23 +13,6 @@ import (
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
-// parentComponentMap maps variant component names to their parent component.
-// When a component's docs are thin, we look up the parent for supplemental content.
-var parentComponentMap = map[string]string{
-	"CVStack":        "Stack",
-	"CHStack":        "Stack",
-	"VStack":         "Stack",
-	"HStack":         "Stack",
-	"ModalDialog":    "Dialog",
-	"AlertDialog":    "Dialog",
-	"DropdownButton": "Button",
-	"IconButton":     "Button",
-	"ToggleButton":   "Button",
-	"NumberBox":      "TextBox",
-	"PasswordBox":    "TextBox",
-	"SearchBox":      "TextBox",
-}
-
 func NewComponentDocsTool(homeDir string) (mcp.Tool, func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)) {
 
 	tool := mcp.NewTool("xmlui_component_docs",
@@ -100,7 +83,7 @@ func NewComponentDocsTool(homeDir string) (mcp.Tool, func(context.Context, mcp.C
 		if section == "" && member == "" {
 			// Supplement thin docs (Rec #3)
 			if len(contentStr) < 500 {
-				supplement := getComponentSupplement(homeDir, componentName)
+				supplement := getComponentSupplement(ctx, homeDir, componentName)
 				if supplement != "" {
 					contentStr += "\n\n---\n## Additional Context\n\n" + supplement
 				}
@@ -144,6 +127,9 @@ func NewComponentDocsTool(homeDir string) (mcp.Tool, func(context.Context, mcp.C
 
 			body := strings.TrimRight(strings.Join(lines[s:e], "\n"), "\n")
 			var b strings.Builder
+			if d, deprecated := memberDeprecation(lines, s, e); deprecated {
+				b.WriteString(deprecationWarning(d) + "\n\n")
+			}
 			b.WriteString("From " + componentName)
 			if contextHeading != "" {
 				b.WriteString(" › " + contextHeading)
@@ -174,7 +160,7 @@ func NewComponentDocsTool(homeDir string) (mcp.Tool, func(context.Context, mcp.C
 }
 
 // getComponentSupplement finds additional documentation for thin component docs.
-func getComponentSupplement(homeDir string, componentName string) string {
+func getComponentSupplement(ctx context.Context, homeDir string, componentName string) string {
 	var supplement strings.Builder
 	maxSupplement := 2000
 	paths := GetRepoPaths(homeDir)
@@ -182,8 +168,8 @@ func getComponentSupplement(homeDir string, componentName string) string {
 	// Extract the bare component name (handle paths like "Stack/VStack")
 	baseName := filepath.Base(componentName)
 
-	// Check parent component map
-	if parent, ok := parentComponentMap[baseName]; ok {
+	// Check the component's family, derived from the component sources
+	if parent := componentFamiliesFor(ctx, homeDir).parent(baseName); parent != "" {
 		parentPath := filepath.Join(homeDir, paths.ComponentDocs, parent+".md")
 		parentContent, err := os.ReadFile(parentPath)
 		if err == nil {
@@ -424,19 +410,23 @@ func h2Range(lines []string, term string) (start, end int, heading string, ok bo
 			continue
 		}
 		if strings.Contains(strings.ToLower(h), lowerTerm) {
-			end = len(lines)
-			for j := i + 1; j < len(lines); j++ {
-				if _, isH2b := h2Heading(lines[j]); isH2b {
-					end = j
-					break
-				}
-			}
-			return i, end, h, true
+			return i, h2End(lines, i), h, true
 		}
 	}
 	return 0, 0, "", false
 }
 
+// h2End returns the end (exclusive) of the "## " section whose heading is
+// lines[start]: the next "## " heading, or EOF.
+func h2End(lines []string, start int) int {
+	for j := start + 1; j < len(lines); j++ {
+		if _, isH2 := h2Heading(lines[j]); isH2 {
+			return j
+		}
+	}
+	return len(lines)
+}
+
 // h3Range searches lines[rangeStart:rangeEnd] for a "### " member heading
 // whose stripped name case-insensitively equals memberName, returning the
 // absolute line range [start,end) spanning that heading through the line
@@ -449,19 +439,23 @@ func h3Range(lines []string, rangeStart, rangeEnd int, memberName string) (start
 		}
 		mn := stripMemberName(strings.TrimPrefix(lines[i], "### "))
 		if strings.EqualFold(mn, memberName) {
-			end = rangeEnd
-			for j := i + 1; j < rangeEnd; j++ {
-				if strings.HasPrefix(lines[j], "### ") || strings.HasPrefix(lines[j], "## ") {
-					end = j
-					break
-				}
-			}
-			return i, end, mn, true
+			return i, h3End(lines, i, rangeEnd), mn, true
 		}
 	}
 	return 0, 0, "", false
 }
 
+// h3End returns the end (exclusive) of the "### " section whose heading is
+// lines[start]: the next "### " or "## " heading, or rangeEnd.
+func h3End(lines []string, start, rangeEnd int) int {
+	for j := start + 1; j < rangeEnd; j++ {
+		if strings.HasPrefix(lines[j], "### ") || strings.HasPrefix(lines[j], "## ") {
+			return j
+		}
+	}
+	return rangeEnd
+}
+
 // enclosingH2At returns the anchor-stripped text of the nearest "## " heading
 // at or before line index idx, or "" if idx precedes any "## " heading.
 func enclosingH2At(lines []string, idx int) string {
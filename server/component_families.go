@@ -0,0 +1,155 @@
+package server
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	// metadataDeclRe matches a component metadata declaration, e.g.
+	// "export const VStackMd = createMetadata({".
+	metadataDeclRe = regexp.MustCompile(`^export\s+const\s+([A-Z]\w*)Md\b[^=]*=`)
+	// metadataReuseRe matches inheritance of another component's metadata:
+	// a spread ("...StackMd") or its props, events or apis
+	// ("TextBoxMd.props"). Borrowing theme or context variables
+	// ("ButtonMd.themeVars") shares styling, not members, so it does not count.
+	metadataReuseRe = regexp.MustCompile(`\.\.\.\s*([A-Z]\w*)Md\b|\b([A-Z]\w*)Md\.(?:props|events|apis)\b`)
+)
+
+// componentFamilies maps each variant component to the component it
+// derives from, as read from the component sources: "VStack" → "Stack".
+type componentFamilies struct {
+	key     string
+	parents map[string]string
+}
+
+var (
+	familiesMu    sync.Mutex
+	familiesCache = map[string]*componentFamilies{} // by corpus tag
+)
+
+// componentFamiliesFor returns the families of homeDir's corpus, deriving
+// them when the corpus tag is new or either underlying index has changed.
+func componentFamiliesFor(ctx context.Context, homeDir string) *componentFamilies {
+	paths := GetRepoPaths(homeDir)
+	docs := searchIndexFor(ctx, homeDir, filepath.Join(homeDir, paths.ComponentDocs), docsIndexExtensions)
+	source := searchIndexFor(ctx, homeDir, filepath.Join(homeDir, paths.ComponentSource), symbolExtensions)
+	if docs == nil || source == nil {
+		return &componentFamilies{parents: map[string]string{}}
+	}
+	tag := corpusVersionForDir(homeDir)
+	key := homeDir + "|" + strconv.FormatUint(docs.generation, 10) + "," + strconv.FormatUint(source.generation, 10)
+
+	familiesMu.Lock()
+	defer familiesMu.Unlock()
+	if cached := familiesCache[tag]; cached != nil && cached.key == key {
+		return cached
+	}
+	documented := make(map[string]bool)
+	for _, schema := range docs.componentSchemas() {
+		documented[schema.Component] = true
+	}
+	families := deriveComponentFamilies(source, documented)
+	families.key = key
+	if ctx.Err() == nil {
+		familiesCache[tag] = families
+	}
+	return families
+}
+
+// deriveComponentFamilies reads each documented component's metadata
+// declaration. A component declared in another documented component's
+// source directory belongs to it (Stack/Stack.tsx declares VStackMd);
+// otherwise one whose declaration reuses another component's metadata
+// belongs to the one it reuses most (NumberBoxMd spreading TextBoxMd.props).
+// Only documented components take part, and no component becomes its own
+// ancestor, however long the chain.
+func deriveComponentFamilies(source *searchIndex, documented map[string]bool) *componentFamilies {
+	families := &componentFamilies{parents: make(map[string]string)}
+	type decl struct {
+		name, dir string
+		refs      map[string]int
+		firstRef  map[string]int
+	}
+	var decls []decl
+	declared := make(map[string]bool)
+	for i := range source.Files {
+		file := &source.Files[i]
+		dir := filepath.Base(filepath.Dir(file.Path))
+		var current *decl
+		for lineNo, line := range file.Lines {
+			if m := metadataDeclRe.FindStringSubmatch(line); m != nil {
+				current = nil
+				if documented[m[1]] && !declared[m[1]] {
+					declared[m[1]] = true
+					decls = append(decls, decl{name: m[1], dir: dir, refs: map[string]int{}, firstRef: map[string]int{}})
+					current = &decls[len(decls)-1]
+				}
+				line = line[len(m[0]):]
+			} else if strings.HasPrefix(line, "export ") || strings.HasPrefix(line, "const ") || strings.HasPrefix(line, "function ") {
+				current = nil
+			}
+			if current == nil {
+				continue
+			}
+			for _, m := range metadataReuseRe.FindAllStringSubmatch(line, -1) {
+				ref := m[1] + m[2]
+				if ref == current.name || !documented[ref] {
+					continue
+				}
+				if _, seen := current.firstRef[ref]; !seen {
+					current.firstRef[ref] = lineNo
+				}
+				current.refs[ref]++
+			}
+		}
+	}
+
+	for _, d := range decls {
+		if d.dir != d.name && documented[d.dir] && !families.descendsFrom(d.dir, d.name) {
+			families.parents[d.name] = d.dir
+		}
+	}
+	for _, d := range decls {
+		if _, ok := families.parents[d.name]; ok || len(d.refs) == 0 {
+			continue
+		}
+		candidates := sortedKeys(d.refs)
+		sort.SliceStable(candidates, func(i, j int) bool {
+			a, b := candidates[i], candidates[j]
+			if d.refs[a] != d.refs[b] {
+				return d.refs[a] > d.refs[b]
+			}
+			return d.firstRef[a] < d.firstRef[b]
+		})
+		for _, parent := range candidates {
+			if !families.descendsFrom(parent, d.name) {
+				families.parents[d.name] = parent
+				break
+			}
+		}
+	}
+	return families
+}
+
+// descendsFrom reports whether name is ancestor or has it among its
+// ancestors, in which case making name ancestor's parent would close a cycle.
+func (f *componentFamilies) descendsFrom(name, ancestor string) bool {
+	for steps := 0; name != "" && steps <= len(f.parents); steps++ {
+		if name == ancestor {
+			return true
+		}
+		name = f.parents[name]
+	}
+	return false
+}
+
+// parent returns the component name derives from, or "".
+func (f *componentFamilies) parent(name string) string {
+	return f.parents[name]
+}
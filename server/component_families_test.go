@@ -0,0 +1,110 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func setupFamilyFixture(t *testing.T) string {
+	t.Helper()
+	ResetRepoPaths()
+	t.Cleanup(ResetRepoPaths)
+	root := t.TempDir()
+	files := map[string]string{
+		"docs/content/components/Stack.md":     "# Stack\n",
+		"docs/content/components/VStack.md":    "# VStack\n",
+		"docs/content/components/TextBox.md":   "# TextBox\n",
+		"docs/content/components/NumberBox.md": "# NumberBox\n",
+		"docs/content/components/Select.md":    "# Select\n",
+		"docs/content/components/Option.md":    "# Option\n",
+		"docs/content/components/Badge.md":     "# Badge\n",
+		"xmlui/src/components/Stack/Stack.tsx": `export const StackMd = createMetadata({ props: {} });
+export const VStackMd = createMetadata({ ...StackMd, props: {} });
+`,
+		"xmlui/src/components/TextBox/TextBox.tsx": "export const TextBoxMd = createMetadata({ props: {} });\n",
+		"xmlui/src/components/NumberBox/NumberBox.tsx": `const COMP = "NumberBox";
+export const NumberBoxMd = createMetadata({
+  props: {
+    placeholder: TextBoxMd.props.placeholder,
+    enabled: TextBoxMd.props.enabled,
+  },
+});
+`,
+		"xmlui/src/components/Select/Select.tsx": `export const SelectMd = createMetadata({
+  description: "Uses OptionMd children",
+});
+`,
+		"xmlui/src/components/Option/Option.tsx": "export const OptionMd = createMetadata({});\n",
+		// Borrowed theme and context variables are styling, not inheritance.
+		"xmlui/src/components/Badge/Badge.tsx": `export const BadgeMd = createMetadata({
+  themeVars: parseScssVar(TextBoxMd.themeVars),
+  contextVars: { $item: OptionMd.contextVars.$item },
+});
+`,
+	}
+	for rel, body := range files {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestComponentFamiliesFromSourceLayoutAndMetadataReuse(t *testing.T) {
+	root := setupFamilyFixture(t)
+	families := componentFamiliesFor(context.Background(), root)
+	want := map[string]string{"VStack": "Stack", "NumberBox": "TextBox"}
+	if len(families.parents) != len(want) {
+		t.Fatalf("parents = %v", families.parents)
+	}
+	for child, parent := range want {
+		if got := families.parent(child); got != parent {
+			t.Fatalf("parent(%s) = %q, want %q", child, got, parent)
+		}
+	}
+	if again := componentFamiliesFor(context.Background(), root); again != families {
+		t.Fatal("families were rebuilt for an unchanged corpus")
+	}
+}
+
+func TestComponentFamiliesNeverFormCycles(t *testing.T) {
+	// A reuses B, B reuses C and C reuses A: the last link would close
+	// the loop, so C stays a root.
+	source := &searchIndex{Files: []indexedFile{
+		{Path: "/src/A/A.tsx", Lines: []string{"export const AMd = createMetadata({ ...BMd });"}},
+		{Path: "/src/B/B.tsx", Lines: []string{"export const BMd = createMetadata({ props: CMd.props });"}},
+		{Path: "/src/C/C.tsx", Lines: []string{"export const CMd = createMetadata({ events: AMd.events });"}},
+	}}
+	families := deriveComponentFamilies(source, map[string]bool{"A": true, "B": true, "C": true})
+	if len(families.parents) != 2 || families.parent("A") != "B" || families.parent("B") != "C" {
+		t.Fatalf("parents = %v", families.parents)
+	}
+}
+
+func TestListComponentsShowsFamilies(t *testing.T) {
+	root := setupFamilyFixture(t)
+	_, handler := NewListComponentsTool(root)
+	result, err := handler(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	for _, want := range []string{
+		"- VStack ← Stack → call xmlui_component_docs with component: \"VStack\"",
+		"- NumberBox ← TextBox → ",
+		"- Select → ",
+	} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("missing %q in:\n%s", want, text)
+		}
+	}
+}
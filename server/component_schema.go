@@ -0,0 +1,450 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// ComponentSchema is a component reference page's member sections in
+// structured form: what xmlui_component_docs returns as Markdown.
+type ComponentSchema struct {
+	Component  string           `json:"component"`
+	URL        string           `json:"url"`
+	Summary    string           `json:"summary,omitempty"` // the overview's first paragraph
+	Deprecated bool             `json:"deprecated,omitempty"`
+	Props      []SchemaMember   `json:"props"`
+	Events     []SchemaMember   `json:"events"`
+	Methods    []SchemaMember   `json:"methods"`
+	ThemeVars  []SchemaThemeVar `json:"theme_vars"`
+}
+
+// SchemaMember is one "### " block of the Properties, Events or Exposed
+// Methods section. Type is the block's signature for events and methods;
+// for props it is given by the page or, failing that, inferred from the
+// default ("boolean", "number" or "string").
+type SchemaMember struct {
+	Name          string   `json:"name"`
+	Type          string   `json:"type,omitempty"`
+	Default       string   `json:"default,omitempty"`
+	AllowedValues []string `json:"allowed_values,omitempty"`
+	Description   string   `json:"description,omitempty"`
+	Deprecated    bool     `json:"deprecated,omitempty"`
+	Line          int      `json:"line"` // 1-based line of the member heading
+}
+
+// SchemaThemeVar is one row of the Styling section's theme variable table.
+// Anchor is that of the heading the table is under.
+type SchemaThemeVar struct {
+	Name        string `json:"name"`
+	Default     string `json:"default,omitempty"`
+	DarkDefault string `json:"dark_default,omitempty"`
+	Anchor      string `json:"anchor,omitempty"`
+	Line        int    `json:"line"`
+}
+
+var (
+	// schemaDefaultRe reads "> [!DEF]  default: **"sm"**" and its variants.
+	schemaDefaultRe = regexp.MustCompile("(?i)default:\\s*(?:\\*\\*|`)?(.+?)(?:\\*\\*|`)?\\s*$")
+	schemaTypeRe    = regexp.MustCompile("(?i)^(?:>\\s*\\[!DEF\\]\\s*)?(?:\\*\\*)?type(?:\\*\\*)?:\\s*(?:\\*\\*)?(.+?)(?:\\*\\*)?\\s*$")
+	// schemaSignatureRe reads "**Signature**: `click(event: MouseEvent): void`".
+	schemaSignatureRe = regexp.MustCompile("(?i)^\\*\\*signature\\*\\*:?\\s*`([^`]+)`")
+	schemaCodeRe      = regexp.MustCompile("`([^`]+)`")
+	schemaNumberRe    = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+)
+
+// componentSchemas parses the index's component reference pages, keyed by
+// absolute path. Like deprecations, it is built on first use and lives as
+// long as the index does.
+func (idx *searchIndex) componentSchemas() map[string]*ComponentSchema {
+	idx.schemasOnce.Do(func() {
+		idx.schemas = make(map[string]*ComponentSchema)
+		for i := range idx.Files {
+			file := &idx.Files[i]
+			if filepath.Ext(file.Name) != ".md" || strings.HasPrefix(file.Name, "_") {
+				continue
+			}
+			idx.schemas[file.Path] = parseComponentSchema(strings.TrimSuffix(file.Name, ".md"), file.Lines)
+		}
+	})
+	return idx.schemas
+}
+
+// documentedSchema is one component reference page's schema and its
+// absolute path.
+type documentedSchema struct {
+	path   string
+	schema *ComponentSchema
+}
+
+// componentDocsIndexes returns the ComponentDocs index, then the
+// ExtensionDocs one when extensions are documented elsewhere.
+func componentDocsIndexes(ctx context.Context, homeDir string) []*searchIndex {
+	paths := GetRepoPaths(homeDir)
+	var indexes []*searchIndex
+	for i, dir := range []string{paths.ComponentDocs, paths.ExtensionDocs} {
+		if dir == "" || (i == 1 && filepath.Clean(dir) == filepath.Clean(paths.ComponentDocs)) {
+			continue
+		}
+		if idx := searchIndexFor(ctx, homeDir, filepath.Join(homeDir, dir), docsIndexExtensions); idx != nil {
+			indexes = append(indexes, idx)
+		}
+	}
+	return indexes
+}
+
+// documentedComponentSchemas returns the schemas of the reference pages in
+// indexes in precedence order: component docs, the pages directly under
+// the first index's root, before extension docs, which are that root's
+// subdirectories (the legacy layout) and any later index. Each group is
+// in sorted path order. Callers wanting one schema per name keep the first
+// they meet, so a component's own page wins over an extension page that
+// shares its name, and the choice does not vary run to run.
+func documentedComponentSchemas(indexes []*searchIndex) []documentedSchema {
+	var core, extensions []documentedSchema
+	for i, idx := range indexes {
+		root := filepath.Clean(idx.Root)
+		for path, schema := range idx.componentSchemas() {
+			doc := documentedSchema{path: path, schema: schema}
+			if i == 0 && filepath.Dir(path) == root {
+				core = append(core, doc)
+			} else {
+				extensions = append(extensions, doc)
+			}
+		}
+	}
+	for _, group := range [][]documentedSchema{core, extensions} {
+		sort.Slice(group, func(a, b int) bool { return group[a].path < group[b].path })
+	}
+	return append(core, extensions...)
+}
+
+// componentSchemaFor returns name's schema from the component and
+// extension docs, matching the file name exactly or, failing that,
+// case-insensitively, in documentedComponentSchemas' precedence order.
+func componentSchemaFor(ctx context.Context, homeDir, name string) (*ComponentSchema, bool) {
+	var folded *ComponentSchema
+	for _, doc := range documentedComponentSchemas(componentDocsIndexes(ctx, homeDir)) {
+		if doc.schema.Component == name {
+			return doc.schema, true
+		}
+		if folded == nil && strings.EqualFold(doc.schema.Component, name) {
+			folded = doc.schema
+		}
+	}
+	return folded, folded != nil
+}
+
+// parseComponentSchema reads a reference page's Properties, Events,
+// Exposed Methods and Styling sections, located the way
+// xmlui_component_docs' section argument locates them.
+func parseComponentSchema(component string, lines []string) *ComponentSchema {
+	schema := &ComponentSchema{
+		Component: component,
+		URL:       ComponentURL(component),
+		Props:     []SchemaMember{},
+		Events:    []SchemaMember{},
+		Methods:   []SchemaMember{},
+		ThemeVars: []SchemaThemeVar{},
+		Summary:   overviewSummary(lines),
+	}
+	notices := parseDeprecations(lines)
+	for _, d := range notices {
+		if d.Kind == DeprecationKindPage {
+			schema.Deprecated = true
+		}
+	}
+	for _, section := range []struct {
+		term    string
+		members *[]SchemaMember
+	}{
+		{"properties", &schema.Props},
+		{"events", &schema.Events},
+		{"methods", &schema.Methods},
+	} {
+		start, end, _, ok := h2Range(lines, section.term)
+		if !ok {
+			continue
+		}
+		for i := start + 1; i < end; i++ {
+			if !strings.HasPrefix(lines[i], "### ") {
+				continue
+			}
+			memberEnd := h3End(lines, i, end)
+			member := parseSchemaMember(lines, i, memberEnd)
+			for _, d := range notices {
+				if d.Line > i && d.Line <= memberEnd {
+					member.Deprecated = true
+				}
+			}
+			*section.members = append(*section.members, member)
+		}
+	}
+	if start, end, _, ok := h2Range(lines, "styling"); ok {
+		schema.ThemeVars = parseThemeVarTable(lines, start, end)
+	}
+	return schema
+}
+
+// overviewSummary returns the first prose paragraph of the page intro,
+// joined onto one line: not the title, a notice or a code sample.
+func overviewSummary(lines []string) string {
+	var paragraph []string
+	inFence := false
+	for _, line := range strings.Split(extractOverview(lines), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "```") {
+			inFence = !inFence
+			continue
+		}
+		switch {
+		case inFence || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ">") || strings.HasPrefix(line, "<") || strings.HasPrefix(line, "|"):
+		case line == "":
+			if len(paragraph) > 0 {
+				return strings.Join(paragraph, " ")
+			}
+		default:
+			paragraph = append(paragraph, line)
+		}
+	}
+	return strings.Join(paragraph, " ")
+}
+
+// parseSchemaMember reads the member block lines[start:end), whose first
+// line is its "### " heading.
+func parseSchemaMember(lines []string, start, end int) SchemaMember {
+	member := SchemaMember{Name: stripMemberName(strings.TrimPrefix(lines[start], "### ")), Line: start + 1}
+	var description []string
+	inDescription, described, inFence := false, false, false
+	for i := start + 1; i < end; i++ {
+		line := strings.TrimSpace(lines[i])
+		lower := strings.ToLower(line)
+		if strings.HasPrefix(line, "```") {
+			inFence = !inFence
+		}
+		switch {
+		case inFence || strings.HasPrefix(line, "```"):
+			if inDescription {
+				inDescription, described = false, true
+			}
+			continue
+		case line == "":
+			if inDescription {
+				inDescription, described = false, true
+			}
+			continue
+		case strings.HasPrefix(line, ">"):
+			if m := schemaTypeRe.FindStringSubmatch(line); m != nil && member.Type == "" {
+				member.Type = strings.Trim(m[1], "`")
+			}
+			if strings.Contains(line, "[!DEF]") {
+				if m := schemaDefaultRe.FindStringSubmatch(line); m != nil {
+					member.Default = strings.Trim(m[1], "`")
+				}
+			}
+			continue
+		case schemaSignatureRe.MatchString(line):
+			member.Type = schemaSignatureRe.FindStringSubmatch(line)[1]
+			continue
+		case schemaTypeRe.MatchString(line) && member.Type == "":
+			member.Type = strings.Trim(schemaTypeRe.FindStringSubmatch(line)[1], "`")
+			continue
+		case strings.HasPrefix(lower, "available values"):
+			var values []string
+			values, i = parseAllowedValues(lines, i, end)
+			member.AllowedValues = append(member.AllowedValues, values...)
+			continue
+		case strings.HasPrefix(line, "|") || strings.HasPrefix(line, "#"):
+			if inDescription {
+				inDescription, described = false, true
+			}
+			continue
+		}
+		if !described {
+			inDescription = true
+			description = append(description, line)
+		}
+	}
+	member.Description = strings.Join(description, " ")
+	if member.Default == "" {
+		for _, v := range member.AllowedValues {
+			if strings.HasSuffix(v, " (default)") {
+				member.Default = strings.TrimSuffix(v, " (default)")
+			}
+		}
+	}
+	for i, v := range member.AllowedValues {
+		member.AllowedValues[i] = strings.TrimSuffix(v, " (default)")
+	}
+	if member.Type == "" {
+		member.Type = inferSchemaType(member.Default)
+	}
+	return member
+}
+
+// parseAllowedValues reads an "Available values:" list starting at
+// lines[i]: backticked values on the same line, or the first column of the
+// table below it. A value marked "**(default)**" keeps a " (default)"
+// suffix for the caller. It returns the values and the last line read.
+func parseAllowedValues(lines []string, i, end int) ([]string, int) {
+	var values []string
+	collect := func(text string) {
+		for _, part := range strings.Split(text, ",") {
+			m := schemaCodeRe.FindStringSubmatch(part)
+			if m == nil {
+				continue
+			}
+			value := m[1]
+			if strings.Contains(part, "(default)") {
+				value += " (default)"
+			}
+			values = append(values, value)
+		}
+	}
+	if _, rest, ok := strings.Cut(lines[i], ":"); ok && strings.TrimSpace(rest) != "" {
+		collect(rest)
+		return values, i
+	}
+	j := i + 1
+	for j < end && strings.TrimSpace(lines[j]) == "" {
+		j++
+	}
+	for ; j < end && strings.HasPrefix(strings.TrimSpace(lines[j]), "|"); j++ {
+		cells := tableCells(lines[j])
+		if len(cells) == 0 || !schemaCodeRe.MatchString(cells[0]) {
+			continue // header or separator row
+		}
+		value := schemaCodeRe.FindStringSubmatch(cells[0])[1]
+		if strings.Contains(lines[j], "(default)") {
+			value += " (default)"
+		}
+		values = append(values, value)
+	}
+	return values, j - 1
+}
+
+// parseThemeVarTable reads the rows of every table in lines[start:end)
+// whose header's first cell is "Variable".
+func parseThemeVarTable(lines []string, start, end int) []SchemaThemeVar {
+	vars := []SchemaThemeVar{}
+	inTable, anchor := false, ""
+	for i := start; i < end; i++ {
+		line := strings.TrimSpace(lines[i])
+		if text, ok := strings.CutPrefix(line, "## "); ok {
+			anchor = headingAnchor(strings.TrimSpace(text))
+		} else if text, ok := strings.CutPrefix(line, "### "); ok {
+			anchor = headingAnchor(strings.TrimSpace(text))
+		}
+		if !strings.HasPrefix(line, "|") {
+			inTable = false
+			continue
+		}
+		cells := tableCells(line)
+		if len(cells) == 0 {
+			continue
+		}
+		if strings.EqualFold(cells[0], "Variable") {
+			inTable = true
+			continue
+		}
+		if !inTable || strings.Trim(cells[0], "-: ") == "" {
+			continue
+		}
+		v := SchemaThemeVar{Name: themeVarCellText(cells[0]), Anchor: anchor, Line: i + 1}
+		if len(cells) > 1 {
+			v.Default = themeVarCellText(cells[1])
+		}
+		if len(cells) > 2 {
+			v.DarkDefault = themeVarCellText(cells[2])
+		}
+		vars = append(vars, v)
+	}
+	return vars
+}
+
+// tableCells splits a Markdown table row into its trimmed cells.
+func tableCells(row string) []string {
+	row = strings.Trim(strings.TrimSpace(row), "|")
+	if row == "" {
+		return nil
+	}
+	cells := strings.Split(row, "|")
+	for i := range cells {
+		cells[i] = strings.TrimSpace(cells[i])
+	}
+	return cells
+}
+
+// themeVarCellText reduces a theme variable table cell to its text: links
+// to their text, without code and emphasis markers, and "*none*" to "".
+func themeVarCellText(cell string) string {
+	cell = mdLinkRe.ReplaceAllString(cell, "$1")
+	cell = strings.NewReplacer("`", "", "*", "", "<br>", " ").Replace(cell)
+	cell = strings.TrimSpace(cell)
+	if strings.EqualFold(cell, "none") {
+		return ""
+	}
+	return cell
+}
+
+// inferSchemaType names the type a default value is written in, or "" for
+// no default.
+func inferSchemaType(value string) string {
+	switch {
+	case value == "":
+		return ""
+	case value == "true" || value == "false":
+		return "boolean"
+	case schemaNumberRe.MatchString(value):
+		return "number"
+	case strings.HasPrefix(value, `"`) || strings.HasPrefix(value, "'"):
+		return "string"
+	}
+	return ""
+}
+
+func NewComponentSchemaTool(homeDir string) (mcp.Tool, func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)) {
+	tool := mcp.NewTool("xmlui_component_schema",
+		mcp.WithDescription("Returns a component's reference page as JSON: each property, event and exposed method with its type or signature, default, allowed values, description and deprecated flag, plus the Styling section's theme variables with their light and dark defaults. Use it instead of xmlui_component_docs when you need prop types or defaults rather than prose."),
+		mcp.WithString("component",
+			mcp.Required(),
+			mcp.Description("Component name, e.g. 'Button', 'Table', or 'Stack/VStack'"),
+		),
+	)
+
+	tool.Annotations = mcp.ToolAnnotation{
+		ReadOnlyHint:    true,
+		DestructiveHint: false,
+		IdempotentHint:  true,
+		OpenWorldHint:   false,
+	}
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		raw, _ := req.Params.Arguments["component"].(string)
+		componentName := normalizeComponentArg(raw)
+		if componentName == "" {
+			return mcp.NewToolResultError("Missing or invalid 'component' parameter"), nil
+		}
+
+		schema, ok := componentSchemaFor(ctx, homeDir, componentName)
+		if ctx.Err() != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Schema lookup %s before the component docs were indexed", searchTruncation(ctx))), nil
+		}
+		if !ok {
+			return mcp.NewToolResultError(componentNotFoundMessage(homeDir, GetRepoPaths(homeDir), componentName)), nil
+		}
+		data, err := json.MarshalIndent(schema, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to encode component schema: %v", err)), nil
+		}
+		return mcp.NewToolResultText(string(data)), nil
+	}
+
+	return tool, handler
+}
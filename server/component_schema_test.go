@@ -0,0 +1,169 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const schemaButtonDoc = `# Button [#button]
+
+A clickable control.
+
+## Properties [#properties]
+
+### ` + "`enabled`" + ` [#enabled]
+
+> [!DEF]  default: **true**
+
+Whether the button responds to clicks.
+
+### ` + "`icon`" + ` [#icon]
+
+> [!WARNING]
+> This property is deprecated. Use [IconButton](/components/IconButton) instead.
+
+The icon to show.
+
+### ` + "`size`" + ` [#size]
+
+> [!DEF]  default: **"sm"**
+
+Sets the size of the button.
+It affects padding too.
+
+Available values:
+
+| Value | Description |
+| :---- | :---------- |
+| ` + "`xs`" + ` | Extra small |
+| ` + "`sm`" + ` | Small **(default)** |
+| ` + "`lg`" + ` | Large |
+
+### ` + "`orientation`" + ` [#orientation]
+
+The main axis.
+
+Available values: ` + "`horizontal`" + ` **(default)**, ` + "`vertical`" + `
+
+` + "```xmlui-pg" + `
+<Button orientation="vertical" />
+` + "```" + `
+
+## Events [#events]
+
+### ` + "`click`" + ` [#click]
+
+Fires when the button is clicked.
+
+**Signature**: ` + "`click(event: MouseEvent): void`" + `
+
+## Exposed Methods [#exposed-methods]
+
+### ` + "`focus`" + ` [#focus]
+
+Focuses the button.
+
+**Signature**: ` + "`focus(): void`" + `
+
+## Styling [#styling]
+
+### Theme Variables [#theme-variables]
+
+| Variable | Default Value (Light) | Default Value (Dark) |
+| --- | --- | --- |
+| [backgroundColor](../styles#color)-Button | $color-primary-500 | $color-primary-400 |
+| [padding](../styles#size)-Button | *none* | *none* |
+`
+
+func TestParseComponentSchema(t *testing.T) {
+	schema := parseComponentSchema("Button", strings.Split(schemaButtonDoc, "\n"))
+
+	want := []SchemaMember{
+		{Name: "enabled", Type: "boolean", Default: "true", Description: "Whether the button responds to clicks.", Line: 7},
+		{Name: "icon", Description: "The icon to show.", Deprecated: true, Line: 13},
+		{Name: "size", Type: "string", Default: `"sm"`, AllowedValues: []string{"xs", "sm", "lg"}, Description: "Sets the size of the button. It affects padding too.", Line: 20},
+		{Name: "orientation", Default: "horizontal", AllowedValues: []string{"horizontal", "vertical"}, Description: "The main axis.", Line: 35},
+	}
+	if !reflect.DeepEqual(schema.Props, want) {
+		t.Fatalf("props:\n got %+v\nwant %+v", schema.Props, want)
+	}
+	if len(schema.Events) != 1 || schema.Events[0].Type != "click(event: MouseEvent): void" || schema.Events[0].Description != "Fires when the button is clicked." {
+		t.Fatalf("events = %+v", schema.Events)
+	}
+	if len(schema.Methods) != 1 || schema.Methods[0].Name != "focus" || schema.Methods[0].Type != "focus(): void" {
+		t.Fatalf("methods = %+v", schema.Methods)
+	}
+	wantVars := []SchemaThemeVar{
+		{Name: "backgroundColor-Button", Default: "$color-primary-500", DarkDefault: "$color-primary-400", Anchor: "theme-variables", Line: 67},
+		{Name: "padding-Button", Anchor: "theme-variables", Line: 68},
+	}
+	if !reflect.DeepEqual(schema.ThemeVars, wantVars) {
+		t.Fatalf("theme vars:\n got %+v\nwant %+v", schema.ThemeVars, wantVars)
+	}
+}
+
+func TestComponentSchemaToolReturnsJSON(t *testing.T) {
+	ResetRepoPaths()
+	t.Cleanup(ResetRepoPaths)
+	root := t.TempDir()
+	componentsDir := filepath.Join(root, "docs", "content", "components")
+	if err := os.MkdirAll(componentsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(componentsDir, "Button.md"), []byte(schemaButtonDoc), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, handler := NewComponentSchemaTool(root)
+
+	var req mcp.CallToolRequest
+	req.Params.Arguments = map[string]interface{}{"component": "button"}
+	result, err := handler(context.Background(), req)
+	if err != nil || result.IsError {
+		t.Fatalf("unexpected error: %v %+v", err, result)
+	}
+	var schema ComponentSchema
+	if err := json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &schema); err != nil {
+		t.Fatal(err)
+	}
+	if schema.Component != "Button" || schema.URL != ComponentURL("Button") || len(schema.Props) != 4 {
+		t.Fatalf("schema = %+v", schema)
+	}
+
+	req.Params.Arguments = map[string]interface{}{"component": "Butt"}
+	result, _ = handler(context.Background(), req)
+	if !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "Did you mean: Button?") {
+		t.Fatalf("miss = %+v", result)
+	}
+}
+
+// A component's own page wins over an extension page of the same name,
+// even one whose path sorts first.
+func TestComponentSchemaPrefersComponentDocs(t *testing.T) {
+	ResetRepoPaths()
+	t.Cleanup(ResetRepoPaths)
+	root := t.TempDir()
+	componentsDir := filepath.Join(root, "docs", "content", "components")
+	extensionDir := filepath.Join(componentsDir, "A-extension")
+	if err := os.MkdirAll(extensionDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(componentsDir, "Button.md"), []byte(schemaButtonDoc), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(extensionDir, "Button.md"), []byte("# Button\n\nAn extension button.\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		schema, ok := componentSchemaFor(context.Background(), root, "Button")
+		if !ok || len(schema.Props) != 4 {
+			t.Fatalf("run %d picked %+v", i, schema)
+		}
+	}
+}
@@ -0,0 +1,332 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Deprecation kinds. A notice before a page's first "## " heading deprecates
+// the page (a component, on a component docs page); one under a "### "
+// member of the Properties, Events or Exposed Methods section deprecates
+// that member; anything else deprecates its section.
+const (
+	DeprecationKindComponent = "component"
+	DeprecationKindPage      = "page"
+	DeprecationKindProp      = "prop"
+	DeprecationKindEvent     = "event"
+	DeprecationKindMethod    = "method"
+	DeprecationKindSection   = "section"
+)
+
+// docDeprecation is one deprecation notice in a docs page: a "[!WARNING]"
+// block, up to its first blank line, that says "deprecated".
+type docDeprecation struct {
+	Line            int    // 1-based line of the "[!WARNING]" marker
+	Kind            string // DeprecationKindPage, or a member or section kind
+	Member          string // the member or section heading; "" for the page
+	Anchor          string // the member's or section's anchor
+	Notice          string // the block's text, without quote and alert markers
+	ReplacementText string // the block's first link, if any
+	ReplacementLink string
+}
+
+// deprecations returns the notices in the index's Markdown files, keyed by
+// absolute path. Like vocabulary, it is built on first use and lives as
+// long as the index does.
+func (idx *searchIndex) deprecations() map[string][]docDeprecation {
+	idx.deprecationsOnce.Do(func() {
+		idx.deprecationsByPath = make(map[string][]docDeprecation)
+		for i := range idx.Files {
+			file := &idx.Files[i]
+			if !hasAllowedExt(file.Path, []string{".md", ".mdx"}) {
+				continue
+			}
+			if found := parseDeprecations(file.Lines); len(found) > 0 {
+				idx.deprecationsByPath[file.Path] = found
+			}
+		}
+	})
+	return idx.deprecationsByPath
+}
+
+// parseDeprecations finds a page's deprecation notices and what each one
+// deprecates, from the headings above it.
+func parseDeprecations(lines []string) []docDeprecation {
+	var out []docDeprecation
+	section, sectionAnchor, member, memberAnchor := "", "", "", ""
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if heading, ok := h2Heading(line); ok {
+			section, sectionAnchor = heading, headingAnchor(strings.TrimSpace(strings.TrimPrefix(line, "## ")))
+			member, memberAnchor = "", ""
+			continue
+		}
+		if strings.HasPrefix(line, "### ") {
+			text := strings.TrimSpace(strings.TrimPrefix(line, "### "))
+			member, memberAnchor = stripMemberName(text), headingAnchor(text)
+			continue
+		}
+		if !strings.Contains(line, "[!WARNING]") {
+			continue
+		}
+		end := i
+		for end < len(lines) && strings.TrimSpace(lines[end]) != "" {
+			end++
+		}
+		block := lines[i:end]
+		start := i
+		i = end
+		if !strings.Contains(strings.ToLower(strings.Join(block, "\n")), "deprecated") {
+			continue
+		}
+
+		d := docDeprecation{Line: start + 1, Notice: deprecationNotice(block)}
+		for _, l := range block {
+			if m := mdLinkRe.FindStringSubmatch(l); m != nil {
+				d.ReplacementText, d.ReplacementLink = m[1], m[2]
+				break
+			}
+		}
+		switch kind := memberKind(section); {
+		case section == "":
+			d.Kind = DeprecationKindPage
+		case member != "" && kind != "":
+			d.Kind, d.Member, d.Anchor = kind, member, memberAnchor
+		case member != "":
+			d.Kind, d.Member, d.Anchor = DeprecationKindSection, member, memberAnchor
+		default:
+			d.Kind, d.Member, d.Anchor = DeprecationKindSection, section, sectionAnchor
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
+// memberKind names the members a component docs section lists, or "" for
+// a section that does not list members.
+func memberKind(section string) string {
+	lower := strings.ToLower(section)
+	switch {
+	case strings.Contains(lower, "propert") || lower == "props":
+		return DeprecationKindProp
+	case strings.Contains(lower, "event"):
+		return DeprecationKindEvent
+	case strings.Contains(lower, "method") || strings.Contains(lower, "api"):
+		return DeprecationKindMethod
+	}
+	return ""
+}
+
+// deprecationNotice joins a warning block's lines without their "> "
+// quote markers and the "[!WARNING]" marker.
+func deprecationNotice(block []string) string {
+	var parts []string
+	for _, l := range block {
+		l = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(l), ">"))
+		l = strings.TrimSpace(strings.Replace(l, "[!WARNING]", "", 1))
+		if l != "" {
+			parts = append(parts, l)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// replacementURL resolves a notice's link: site-absolute links against the
+// docs site, anything else as written.
+func replacementURL(link string) string {
+	if strings.HasPrefix(link, "/") {
+		return constructURLBase() + link
+	}
+	return link
+}
+
+// markDeprecated flags sf from the registry of whichever index holds it,
+// with the first replacement link any of its notices gives.
+func markDeprecated(sf *scoredFile, indexes []*searchIndex) {
+	for _, idx := range indexes {
+		notices := idx.deprecations()[sf.AbsPath]
+		if len(notices) == 0 {
+			continue
+		}
+		sf.Deprecated = true
+		for _, d := range notices {
+			if d.ReplacementLink != "" {
+				sf.ReplacementText, sf.ReplacementLink = d.ReplacementText, d.ReplacementLink
+				break
+			}
+		}
+		return
+	}
+}
+
+// memberDeprecation returns the notice inside a member's block, lines
+// [start,end) of a component docs page, if there is one.
+func memberDeprecation(lines []string, start, end int) (docDeprecation, bool) {
+	for _, d := range parseDeprecations(lines) {
+		if d.Line > start && d.Line <= end && d.Member != "" {
+			return d, true
+		}
+	}
+	return docDeprecation{}, false
+}
+
+// deprecationWarning is the one-line warning shown above deprecated
+// content, matching the search tools' DEPRECATED line.
+func deprecationWarning(d docDeprecation) string {
+	if d.ReplacementLink != "" {
+		return fmt.Sprintf("**DEPRECATED**: Use [%s](%s) instead.", d.ReplacementText, replacementURL(d.ReplacementLink))
+	}
+	return "**DEPRECATED**: " + d.Notice
+}
+
+// registeredDeprecation is a registry entry resolved against the corpus:
+// which component or page it belongs to, and where.
+type registeredDeprecation struct {
+	docDeprecation
+	Component string // component name on component docs pages
+	Page      string // page title elsewhere
+	Path      string // repo-relative
+	URL       string
+}
+
+// deprecationRoots are the docs directories the registry covers.
+func deprecationRoots(homeDir string) (roots []string, componentRoots map[string]bool) {
+	paths := GetRepoPaths(homeDir)
+	componentRoots = make(map[string]bool)
+	seen := make(map[string]bool)
+	for _, dir := range []string{paths.ComponentDocs, paths.ExtensionDocs, paths.Pages} {
+		if dir == "" || seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		root := filepath.Join(homeDir, dir)
+		roots = append(roots, root)
+		componentRoots[root] = dir != paths.Pages
+	}
+	return roots, componentRoots
+}
+
+// deprecationRegistry lists every notice under the docs roots, ordered by
+// component or page name, then path and line.
+func deprecationRegistry(ctx context.Context, homeDir string) []registeredDeprecation {
+	roots, componentRoots := deprecationRoots(homeDir)
+	var out []registeredDeprecation
+	seen := make(map[string]bool)
+	for _, root := range roots {
+		idx := searchIndexFor(ctx, homeDir, root, docsIndexExtensions)
+		if idx == nil {
+			continue
+		}
+		notices := idx.deprecations()
+		for i := range idx.Files {
+			file := &idx.Files[i]
+			if seen[file.Path] || len(notices[file.Path]) == 0 {
+				continue
+			}
+			seen[file.Path] = true
+			rel := toRepoRelative(homeDir, file.Path)
+			component, page := "", pageTitle(file.Lines)
+			if componentRoots[root] {
+				component, page = strings.TrimSuffix(file.Name, filepath.Ext(file.Name)), ""
+			}
+			for _, d := range notices[file.Path] {
+				r := registeredDeprecation{docDeprecation: d, Component: component, Page: page, Path: rel, URL: constructDocURL(rel)}
+				if r.Kind == DeprecationKindPage && component != "" {
+					r.Kind = DeprecationKindComponent
+				}
+				if r.URL != "" && r.Anchor != "" {
+					r.URL += "#" + r.Anchor
+				}
+				out = append(out, r)
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i].Component+out[i].Page, out[j].Component+out[j].Page
+		if a != b {
+			return a < b
+		}
+		if out[i].Path != out[j].Path {
+			return out[i].Path < out[j].Path
+		}
+		return out[i].Line < out[j].Line
+	})
+	return out
+}
+
+func NewDeprecationsTool(homeDir string) (mcp.Tool, func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)) {
+	tool := mcp.NewTool("xmlui_deprecations",
+		mcp.WithDescription("Lists deprecated XMLUI components, properties, events, methods and docs pages, with each notice's replacement and docs URL. Check it before recommending a component or prop from memory."),
+		mcp.WithString("component",
+			mcp.Description("Optional component name, e.g. 'Button', to list only that component's deprecations. Case-insensitive."),
+		),
+	)
+
+	tool.Annotations = mcp.ToolAnnotation{
+		ReadOnlyHint:    true,
+		DestructiveHint: false,
+		IdempotentHint:  true,
+		OpenWorldHint:   false,
+	}
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		component, _ := req.Params.Arguments["component"].(string)
+		component = normalizeComponentArg(component)
+
+		registry := deprecationRegistry(ctx, homeDir)
+		if ctx.Err() != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Deprecation lookup %s before the docs were indexed", searchTruncation(ctx))), nil
+		}
+		var entries []registeredDeprecation
+		for _, r := range registry {
+			if component == "" || strings.EqualFold(r.Component, component) {
+				entries = append(entries, r)
+			}
+		}
+
+		var out strings.Builder
+		switch {
+		case len(entries) == 0 && component != "":
+			fmt.Fprintf(&out, "No deprecations recorded for %s.\n", component)
+		case len(entries) == 0:
+			out.WriteString("No deprecations recorded.\n")
+		default:
+			fmt.Fprintf(&out, "Deprecations (%d):\n", len(entries))
+		}
+		for _, r := range entries {
+			out.WriteString("\n## " + deprecationSubject(r) + "  (" + r.Kind + ")\n")
+			fmt.Fprintf(&out, "  %s:%d\n", r.Path, r.Line)
+			if r.URL != "" {
+				fmt.Fprintf(&out, "  URL: %s\n", r.URL)
+			}
+			if r.ReplacementLink != "" {
+				fmt.Fprintf(&out, "  Replacement: [%s](%s)\n", r.ReplacementText, replacementURL(r.ReplacementLink))
+			}
+			fmt.Fprintf(&out, "  Notice: %s\n", r.Notice)
+		}
+		return mcp.NewToolResultText(out.String()), nil
+	}
+
+	return tool, handler
+}
+
+// deprecationSubject names what an entry deprecates: "Button", "Button ›
+// icon", or a page title and section.
+func deprecationSubject(r registeredDeprecation) string {
+	owner := r.Component
+	if owner == "" {
+		owner = r.Page
+	}
+	if owner == "" {
+		owner = r.Path
+	}
+	if r.Member == "" {
+		return owner
+	}
+	return owner + " › " + r.Member
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const deprecatedButtonDoc = `# Button [#button]
+
+A clickable control.
+
+## Properties [#properties]
+
+### ` + "`icon`" + ` [#icon]
+
+> [!WARNING]
+> This property is deprecated. Use [IconButton](/components/IconButton) instead.
+
+The icon to show.
+
+### ` + "`label`" + ` [#label]
+
+The text to show.
+
+## Events [#events]
+
+### ` + "`click`" + `
+
+> [!WARNING]
+> Deprecated: handle onPress.
+`
+
+func TestParseDeprecationsAttributesNoticesToHeadings(t *testing.T) {
+	got := parseDeprecations(append([]string{"# Old", "> [!WARNING]", "> This page is deprecated.", ""}, strings.Split(deprecatedButtonDoc, "\n")...))
+	if len(got) != 3 {
+		t.Fatalf("notices = %+v", got)
+	}
+	if got[0].Kind != DeprecationKindPage || got[0].Member != "" || got[0].Notice != "This page is deprecated." {
+		t.Fatalf("page notice = %+v", got[0])
+	}
+	if got[1].Kind != DeprecationKindProp || got[1].Member != "icon" || got[1].Anchor != "icon" || got[1].ReplacementLink != "/components/IconButton" {
+		t.Fatalf("prop notice = %+v", got[1])
+	}
+	if got[2].Kind != DeprecationKindEvent || got[2].Member != "click" || got[2].ReplacementLink != "" {
+		t.Fatalf("event notice = %+v", got[2])
+	}
+	if parseDeprecations([]string{"> [!WARNING]", "> Experimental."}) != nil {
+		t.Fatal("a warning without 'deprecated' was recorded")
+	}
+}
+
+func setupDeprecationFixture(t *testing.T) string {
+	t.Helper()
+	ResetRepoPaths()
+	t.Cleanup(ResetRepoPaths)
+	root := t.TempDir()
+	componentsDir := filepath.Join(root, "docs", "content", "components")
+	if err := os.MkdirAll(componentsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"Button.md": deprecatedButtonDoc,
+		"Text.md":   "# Text\n\nDisplays text.\n",
+	}
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(componentsDir, name), []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestDeprecationsToolFiltersByComponent(t *testing.T) {
+	root := setupDeprecationFixture(t)
+	_, handler := NewDeprecationsTool(root)
+
+	var req mcp.CallToolRequest
+	req.Params.Arguments = map[string]interface{}{"component": "button"}
+	result, err := handler(context.Background(), req)
+	if err != nil || result.IsError {
+		t.Fatalf("unexpected error: %v %+v", err, result)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	for _, want := range []string{
+		"Deprecations (2):",
+		"## Button › icon  (prop)",
+		"docs/content/components/Button.md:9",
+		"URL: " + ComponentURL("Button") + "#icon",
+		"Replacement: [IconButton](" + constructURLBase() + "/components/IconButton)",
+		"## Button › click  (event)",
+	} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("missing %q in:\n%s", want, text)
+		}
+	}
+
+	req.Params.Arguments = map[string]interface{}{"component": "Text"}
+	result, _ = handler(context.Background(), req)
+	if text := result.Content[0].(mcp.TextContent).Text; text != "No deprecations recorded for Text.\n" {
+		t.Fatalf("text = %q", text)
+	}
+}
+
+func TestComponentDocsWarnsAboveDeprecatedMember(t *testing.T) {
+	root := setupDeprecationFixture(t)
+	_, handler := NewComponentDocsTool(root)
+
+	var req mcp.CallToolRequest
+	req.Params.Arguments = map[string]interface{}{"component": "Button", "member": "icon"}
+	result, err := handler(context.Background(), req)
+	if err != nil || result.IsError {
+		t.Fatalf("unexpected error: %v %+v", err, result)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	if !strings.HasPrefix(text, "**DEPRECATED**: Use [IconButton]("+constructURLBase()+"/components/IconButton) instead.\n\nFrom Button › Properties:") {
+		t.Fatalf("missing warning:\n%s", text)
+	}
+
+	req.Params.Arguments = map[string]interface{}{"component": "Button", "member": "label"}
+	result, _ = handler(context.Background(), req)
+	if text := result.Content[0].(mcp.TextContent).Text; strings.Contains(text, "DEPRECATED") {
+		t.Fatalf("label is not deprecated:\n%s", text)
+	}
+}
+
+func TestSearchMarksDeprecatedFilesFromRegistry(t *testing.T) {
+	resetTopicIndexForTest(t)
+	root := t.TempDir()
+	howtoDir := filepath.Join(root, "howto")
+	if err := os.MkdirAll(howtoDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// The only hit is the title, above the notice.
+	writeHowtoFixture(t, howtoDir, "old-forms.md", "# Legacy forms\n\n> [!WARNING]\n> Deprecated. See [Forms](/forms).\n")
+
+	human, _, err := ExecuteMediatedSearch(root, howtoMediatorConfig(howtoDir), "legacy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(human, "**DEPRECATED**: Use [Forms]("+constructURLBase()+"/forms) instead.") {
+		t.Fatalf("deprecated file not marked:\n%s", human)
+	}
+}
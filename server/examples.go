@@ -2,18 +2,34 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
+// exampleIndexExtensions is xmlui_examples' extension list. Other tools
+// that read the example roots index them with it, so they share its indexes.
+var exampleIndexExtensions = []string{".tsx", ".xmlui", ".mdx", ".md"}
+
 // NewExamplesTool wires xmlui_examples to the shared search mediator.
 func NewExamplesTool(exampleRoots []string) (mcp.Tool, func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)) {
 	WriteDebugLog("Example roots configured: %v\n", exampleRoots)
 
 	tool := mcp.NewTool("xmlui_examples",
-		mcp.WithDescription("Searches local sample apps for usage examples of XMLUI components using a staged search mediator. Returns human-readable matches plus a JSON summary."),
-		mcp.WithString("query", mcp.Required(), mcp.Description("Search term, e.g. 'Spinner', 'AppState', or 'delay=\"1000\"'")),
+		mcp.WithDescription("Searches local sample apps for usage examples of XMLUI components using a staged search mediator. Returns human-readable matches; pass format 'json' or 'both' for the structured MediatorJSON summary."),
+		mcp.WithString("query", mcp.Required(), mcp.Description("Search term, e.g. 'Spinner', 'AppState', or 'delay=\"1000\"'"+queryGrammarHint)),
+		withSearchFormat(),
+		withSearchOffset(),
+		withSearchCursor(),
+		withSearchContextLines(),
+		withSearchFuzzy(),
+		withSearchExplain(),
+		withSearchHighlight(),
+		mcp.WithString("mode",
+			mcp.Enum(SearchModeWords, SearchModeMarkup),
+			mcp.Description("Optional match mode. 'words' (default) runs the staged word search; 'markup' treats query as a CSS-like selector over the parsed .xmlui files, e.g. 'Table > Column[canSort]' (direct child), 'Form TextBox[required=true]' (any descendant) or 'Button[onClick*=navigate]' (*=, ^=, $= match part of a value), and returns each enclosing element's source range. Markup mode does not page."),
+		),
 	)
 
 	tool.Annotations = mcp.ToolAnnotation{
@@ -29,27 +45,70 @@ func NewExamplesTool(exampleRoots []string) (mcp.Tool, func(context.Context, mcp
 			return mcp.NewToolResultError("Missing or invalid 'query' parameter"), nil
 		}
 		query := strings.TrimSpace(raw)
+		format, err := searchFormatArgument(req)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		contextLines, err := searchContextLinesArgument(req)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		fuzzy, err := searchBoolArgument(req, "fuzzy", false)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		explain, err := searchBoolArgument(req, "explain", false)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		highlight, err := searchBoolArgument(req, "highlight", false)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		mode, _ := req.Params.Arguments["mode"].(string)
+		switch mode {
+		case "", SearchModeWords:
+			mode = ""
+		case SearchModeMarkup:
+		default:
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid 'mode' parameter %q: use 'words' or 'markup'", mode)), nil
+		}
 
+		dict := CurrentSearchDictionary()
 		cfg := MediatorConfig{
 			Roots:                 exampleRoots,
 			SectionKeys:           []string{"examples"},
 			PreferSections:        []string{"examples"}, // bias towards examples (though all are examples)
 			MaxResults:            50,
-			FileExtensions:        []string{".tsx", ".xmlui", ".mdx", ".md"},
-			Stopwords:             DefaultStopwords(),
-			Synonyms:              DefaultSynonyms(),
+			FileExtensions:        exampleIndexExtensions,
+			Stopwords:             dict.Stopwords,
+			Synonyms:              dict.Synonyms,
 			Classifier:            ExamplesClassifier(),
 			EnableFilenameMatches: true,
 			ToolName:              "xmlui_examples",
+			ContextLines:          contextLines,
+			Timeout:               SearchTimeout(),
+			Scorer:                SearchScorer(),
+			FuzzyTokens:           fuzzy,
+			Explain:               explain,
+			Highlight:             highlight,
+			Mode:                  mode,
+		}
+		cfg.Offset, err = searchPagingArgument(req, cfg, query)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if mode == SearchModeMarkup && cfg.Offset > 0 {
+			return mcp.NewToolResultError("Markup mode does not page: add a step or attribute test to the selector instead of passing offset or cursor"), nil
 		}
 
 		// Use the common parent of example roots for relative paths
 		homeDir := commonParent(exampleRoots)
-		human, err := ExecuteMediatedSearchWithAnalytics(ctx, "xmlui_examples", homeDir, cfg, query)
+		human, summary, err := ExecuteMediatedSearchWithAnalytics(ctx, "xmlui_examples", homeDir, cfg, query)
 		if err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
-		return mcp.NewToolResultText(human), nil
+		return searchToolResult(format, human, summary), nil
 	}
 
 	return tool, handler
@@ -0,0 +1,324 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Symbol kinds reported by xmlui_find_symbol.
+const (
+	SymbolKindFunction  = "function"
+	SymbolKindConst     = "const"
+	SymbolKindInterface = "interface"
+	SymbolKindType      = "type"
+	SymbolKindClass     = "class"
+	SymbolKindComponent = "component"
+)
+
+// maxSymbolSuggestions caps the near names offered when nothing matches.
+const maxSymbolSuggestions = 5
+
+// symbolExtensions are the files xmlui_find_symbol parses.
+var symbolExtensions = []string{".ts", ".tsx"}
+
+// exportPattern matches the export declarations the tool understands, one
+// per line: "export [default] [async] function name", "export const name",
+// "export [declare] interface name", "export type name", and
+// "export [default] [abstract] class name". Re-exports ("export { a } from")
+// are not definitions and are left out.
+var exportPattern = regexp.MustCompile(`^\s*export\s+(?:default\s+)?(?:declare\s+)?(?:async\s+)?(?:abstract\s+)?(function\*?|const|let|var|interface|type|class)\s+([A-Za-z_$][\w$]*)`)
+
+// componentInitializer marks a PascalCase const as a React component: an
+// arrow function or a forwardRef/memo wrapper on the declaration line.
+var componentInitializer = regexp.MustCompile(`=>|\bforwardRef\s*[(<]|\bmemo\s*\(`)
+
+// symbolDef is one exported declaration.
+type symbolDef struct {
+	Name string
+	Kind string
+	Path string // repo-relative
+	Line int    // 1-based
+	Text string // the declaration line, trimmed
+}
+
+// symbolTable is the exported declarations of the component sources, keyed
+// by name. It is rebuilt when the underlying index is.
+type symbolTable struct {
+	generation uint64
+	byName     map[string][]symbolDef
+}
+
+var (
+	symbolMu    sync.Mutex
+	symbolCache *symbolTable
+)
+
+func NewFindSymbolTool(homeDir string) (mcp.Tool, func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)) {
+	tool := mcp.NewTool("xmlui_find_symbol",
+		mcp.WithDescription("Finds where an exported TypeScript symbol is defined in the XMLUI component sources (.ts/.tsx), e.g. 'useTheme' or 'ButtonProps'. Returns each definition's kind, file and line, and how often the name is referenced across those sources. Answers 'where is X defined' in one call, without searching docs."),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("Exact symbol name, case-sensitive. When nothing matches exactly, case-insensitive matches and near names are suggested."),
+		),
+		mcp.WithString("kind",
+			mcp.Enum(SymbolKindFunction, SymbolKindConst, SymbolKindInterface, SymbolKindType, SymbolKindClass, SymbolKindComponent),
+			mcp.Description("Optional kind filter. Components are PascalCase functions, and PascalCase consts bound to an arrow function, forwardRef or memo, in .tsx files."),
+		),
+	)
+
+	tool.Annotations = mcp.ToolAnnotation{
+		ReadOnlyHint:    true,
+		DestructiveHint: false,
+		IdempotentHint:  true,
+		OpenWorldHint:   false,
+	}
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		raw, ok := req.Params.Arguments["name"].(string)
+		name := strings.TrimSpace(raw)
+		if !ok || name == "" {
+			return mcp.NewToolResultError("Missing or invalid 'name' parameter"), nil
+		}
+		kind, _ := req.Params.Arguments["kind"].(string)
+		switch kind {
+		case "", SymbolKindFunction, SymbolKindConst, SymbolKindInterface, SymbolKindType, SymbolKindClass, SymbolKindComponent:
+		default:
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid 'kind' parameter %q: use function, const, interface, type, class, or component", kind)), nil
+		}
+
+		paths := GetRepoPaths(homeDir)
+		if paths.ComponentSource == "" {
+			return mcp.NewToolResultError("No component source directory is configured for this XMLUI version"), nil
+		}
+		idx := searchIndexFor(ctx, homeDir, filepath.Join(homeDir, paths.ComponentSource), symbolExtensions)
+		if ctx.Err() != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Symbol search %s before the sources were indexed", searchTruncation(ctx))), nil
+		}
+		if idx == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to index %s", paths.ComponentSource)), nil
+		}
+		table := symbolTableFor(homeDir, idx)
+
+		var defs []symbolDef
+		for _, def := range table.byName[name] {
+			if kind == "" || def.Kind == kind {
+				defs = append(defs, def)
+			}
+		}
+		if len(defs) == 0 {
+			return mcp.NewToolResultText(formatSymbolMiss(name, kind, table)), nil
+		}
+		refs, files := symbolReferences(homeDir, idx, name, defs)
+		return mcp.NewToolResultText(formatSymbolDefs(defs, refs, files)), nil
+	}
+
+	return tool, handler
+}
+
+// symbolTableFor returns the table for idx, parsing the sources when the
+// index has changed since the last call.
+func symbolTableFor(homeDir string, idx *searchIndex) *symbolTable {
+	symbolMu.Lock()
+	defer symbolMu.Unlock()
+	if symbolCache != nil && symbolCache.generation == idx.generation {
+		return symbolCache
+	}
+	table := &symbolTable{generation: idx.generation, byName: make(map[string][]symbolDef)}
+	for i := range idx.Files {
+		file := &idx.Files[i]
+		rel := toRepoRelative(homeDir, file.Path)
+		for _, def := range parseExports(file.Lines, strings.HasSuffix(file.Path, ".tsx")) {
+			def.Path = rel
+			table.byName[def.Name] = append(table.byName[def.Name], def)
+		}
+	}
+	symbolCache = table
+	return table
+}
+
+// parseExports lists the export declarations in a TypeScript file's lines.
+// It is a line scanner, not a parser: declarations inside block comments
+// are skipped, and those in template literals are not expected.
+func parseExports(lines []string, tsx bool) []symbolDef {
+	var defs []symbolDef
+	inComment := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if inComment {
+			if strings.Contains(trimmed, "*/") {
+				inComment = false
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "/*") && !strings.Contains(trimmed, "*/") {
+			inComment = true
+			continue
+		}
+		m := exportPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		def := symbolDef{Name: m[2], Line: i + 1, Text: trimmed}
+		switch m[1] {
+		case "function", "function*":
+			def.Kind = SymbolKindFunction
+			if tsx && isPascalCase(def.Name) {
+				def.Kind = SymbolKindComponent
+			}
+		case "const", "let", "var":
+			def.Kind = SymbolKindConst
+			if tsx && isPascalCase(def.Name) && componentInitializer.MatchString(line[len(m[0]):]) {
+				def.Kind = SymbolKindComponent
+			}
+		case "interface":
+			def.Kind = SymbolKindInterface
+		case "type":
+			def.Kind = SymbolKindType
+		case "class":
+			def.Kind = SymbolKindClass
+		}
+		defs = append(defs, def)
+	}
+	return defs
+}
+
+func isPascalCase(name string) bool {
+	for _, r := range name {
+		return unicode.IsUpper(r)
+	}
+	return false
+}
+
+// symbolReferences counts whole-word, case-sensitive occurrences of name in
+// the indexed sources, and the files holding them, leaving out the
+// definition lines themselves.
+func symbolReferences(homeDir string, idx *searchIndex, name string, defs []symbolDef) (refs, files int) {
+	definitions := make(map[string]bool, len(defs))
+	for _, def := range defs {
+		definitions[def.Path+":"+strconv.Itoa(def.Line)] = true
+	}
+	candidate := idx.candidateFiles([]string{strings.ToLower(name)}, 1)
+	for i := range idx.Files {
+		if !candidate[i] {
+			continue
+		}
+		file := &idx.Files[i]
+		rel := toRepoRelative(homeDir, file.Path)
+		inFile := 0
+		for n, line := range file.Lines {
+			count := countWord(line, name)
+			if count > 0 && definitions[rel+":"+strconv.Itoa(n+1)] {
+				count--
+			}
+			inFile += count
+		}
+		if inFile > 0 {
+			refs += inFile
+			files++
+		}
+	}
+	return refs, files
+}
+
+// countWord counts occurrences of name in line not flanked by identifier
+// characters, so "useTheme" does not count "useThemeVars".
+func countWord(line, name string) int {
+	count := 0
+	for from := 0; ; {
+		i := strings.Index(line[from:], name)
+		if i < 0 {
+			return count
+		}
+		start, end := from+i, from+i+len(name)
+		if (start == 0 || !isIdentByte(line[start-1])) && (end == len(line) || !isIdentByte(line[end])) {
+			count++
+		}
+		from = end
+	}
+}
+
+// isIdentByte reports whether b can be part of a TypeScript identifier.
+// Bytes of multi-byte runes count, erring toward "same identifier".
+func isIdentByte(b byte) bool {
+	return b == '_' || b == '$' || b >= 0x80 ||
+		'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || '0' <= b && b <= '9'
+}
+
+func formatSymbolDefs(defs []symbolDef, refs, files int) string {
+	sort.Slice(defs, func(i, j int) bool {
+		if defs[i].Path == defs[j].Path {
+			return defs[i].Line < defs[j].Line
+		}
+		return defs[i].Path < defs[j].Path
+	})
+	var out strings.Builder
+	for _, def := range defs {
+		fmt.Fprintf(&out, "%s  (%s)\n", def.Name, def.Kind)
+		fmt.Fprintf(&out, "  Defined: %s:%d\n", def.Path, def.Line)
+		fmt.Fprintf(&out, "    %s\n", def.Text)
+	}
+	fmt.Fprintf(&out, "References: %d (files=%d; whole-word, excluding definitions)\n", refs, files)
+	return out.String()
+}
+
+// formatSymbolMiss explains a name with no definition: any case-insensitive
+// matches, else names within two edits or containing it.
+func formatSymbolMiss(name, kind string, table *symbolTable) string {
+	lower := strings.ToLower(name)
+	var exact, near []string
+	for candidate, defs := range table.byName {
+		if kind != "" && !hasSymbolKind(defs, kind) {
+			continue
+		}
+		cl := strings.ToLower(candidate)
+		switch {
+		case cl == lower:
+			exact = append(exact, candidate)
+		case levenshtein(lower, cl) <= 2 || len(lower) >= 4 && strings.Contains(cl, lower):
+			near = append(near, candidate)
+		}
+	}
+	suggestions := exact
+	if len(suggestions) == 0 {
+		suggestions = near
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		di, dj := levenshtein(lower, strings.ToLower(suggestions[i])), levenshtein(lower, strings.ToLower(suggestions[j]))
+		if di == dj {
+			return suggestions[i] < suggestions[j]
+		}
+		return di < dj
+	})
+	if len(suggestions) > maxSymbolSuggestions {
+		suggestions = suggestions[:maxSymbolSuggestions]
+	}
+
+	var out strings.Builder
+	if kind != "" {
+		fmt.Fprintf(&out, "No exported %s named %s in the component sources.\n", kind, name)
+	} else {
+		fmt.Fprintf(&out, "No exported symbol named %s in the component sources.\n", name)
+	}
+	if len(suggestions) > 0 {
+		fmt.Fprintf(&out, "Did you mean: %s?\n", strings.Join(suggestions, ", "))
+	}
+	return out.String()
+}
+
+func hasSymbolKind(defs []symbolDef, kind string) bool {
+	for _, def := range defs {
+		if def.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestParseExportsClassifiesDeclarations(t *testing.T) {
+	lines := []string{
+		"/*",
+		"export const Hidden = 1;",
+		"*/",
+		"export function useTheme() {",
+		"export const Button = forwardRef(function Button(props, ref) {",
+		"export const defaultProps = { size: 'sm' };",
+		"export default function Card() {",
+		"export interface ButtonProps {",
+		"export type Size = 'sm' | 'md';",
+		"export { useTheme as useThemeAlias } from './theme';",
+	}
+	var got []string
+	for _, def := range parseExports(lines, true) {
+		got = append(got, def.Kind+" "+def.Name)
+	}
+	want := []string{
+		"function useTheme",
+		"component Button",
+		"const defaultProps",
+		"component Card",
+		"interface ButtonProps",
+		"type Size",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("exports = %v\nwant %v", got, want)
+	}
+}
+
+func TestFindSymbolReportsDefinitionAndReferences(t *testing.T) {
+	ResetRepoPaths()
+	t.Cleanup(ResetRepoPaths)
+	root := t.TempDir()
+	dir := filepath.Join(root, "xmlui", "src", "components", "Theme")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"ThemeContext.tsx": "export function useTheme() {\n  return useContext(ThemeContext);\n}\n",
+		"Button.tsx":       "import { useTheme } from './ThemeContext';\nexport const Button = () => {\n  const t = useTheme();\n  const v = useThemeVars();\n};\n",
+	}
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, handler := NewFindSymbolTool(root)
+	call := func(args map[string]interface{}) string {
+		t.Helper()
+		var req mcp.CallToolRequest
+		req.Params.Arguments = args
+		result, err := handler(context.Background(), req)
+		if err != nil || result.IsError {
+			t.Fatalf("unexpected error: %v %+v", err, result)
+		}
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	text := call(map[string]interface{}{"name": "useTheme"})
+	for _, want := range []string{
+		"useTheme  (function)",
+		"Defined: xmlui/src/components/Theme/ThemeContext.tsx:1",
+		"References: 2 (files=1;",
+	} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("missing %q in:\n%s", want, text)
+		}
+	}
+
+	if text := call(map[string]interface{}{"name": "usetheme"}); !strings.Contains(text, "Did you mean: useTheme?") {
+		t.Fatalf("expected a case-insensitive suggestion:\n%s", text)
+	}
+	if text := call(map[string]interface{}{"name": "useTheme", "kind": "component"}); !strings.Contains(text, "No exported component named useTheme") {
+		t.Fatalf("kind filter not applied:\n%s", text)
+	}
+}
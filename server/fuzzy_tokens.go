@@ -0,0 +1,163 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// minFuzzyTokenLength is the shortest token the fuzzy stage corrects. One
+// edit away from a three-letter word is almost any other three-letter word.
+const minFuzzyTokenLength = 4
+
+// maxFuzzyEdits is the edit distance the fuzzy stage accepts for a token of
+// the given length: one for short words, two from six letters on
+// ("Datasourse" → "DataSource", "onClik" → "onClick").
+func maxFuzzyEdits(n int) int {
+	if n >= 6 {
+		return 2
+	}
+	return 1
+}
+
+// withSearchFuzzy adds the shared "fuzzy" argument.
+func withSearchFuzzy() mcp.ToolOption {
+	return mcp.WithBoolean("fuzzy",
+		mcp.Description("Optional, default false. Pass true to correct query words that occur nowhere in the searched files to the nearest word that does, e.g. 'Datasourse' → 'DataSource'; corrections are listed in the result. Off, words are searched exactly as typed, so partial identifiers still match by substring."),
+	)
+}
+
+// vocabWord is one corpus word: its most frequent spelling, for display,
+// and how many times it occurs.
+type vocabWord struct {
+	form  string
+	count int
+}
+
+// vocabulary returns the index's words, keyed by lowercase form. It is
+// built on first use and lives as long as the index does.
+func (idx *searchIndex) vocabulary() map[string]vocabWord {
+	idx.vocabOnce.Do(func() {
+		forms := map[string]map[string]int{}
+		for _, file := range idx.Files {
+			for _, line := range file.Lines {
+				for _, word := range strings.FieldsFunc(line, isNotWordRune) {
+					if len(word) < minFuzzyTokenLength {
+						continue
+					}
+					lower := strings.ToLower(word)
+					if forms[lower] == nil {
+						forms[lower] = map[string]int{}
+					}
+					forms[lower][word]++
+				}
+			}
+		}
+		idx.vocab = make(map[string]vocabWord, len(forms))
+		for lower, spellings := range forms {
+			var best vocabWord
+			for form, n := range spellings {
+				best.count += n
+				if n > spellings[best.form] || n == spellings[best.form] && form < best.form {
+					best.form = form
+				}
+			}
+			idx.vocab[lower] = best
+		}
+	})
+	return idx.vocab
+}
+
+func isNotWordRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// tokenCorrection is one token the fuzzy stage replaced.
+type tokenCorrection struct {
+	From    string // the query token, lowercase
+	To      string // the corpus word, lowercase, as the stages match it
+	Display string // the corpus word's usual spelling
+}
+
+// correctTokens finds, for each token absent from every index's vocabulary,
+// the nearest corpus word within maxFuzzyEdits: smallest distance first,
+// then the more frequent word, then alphabetical. Tokens already in the
+// corpus, too short, carrying punctuation (paths, $props.x), or with no near
+// word are left alone.
+func correctTokens(tokens []string, indexes []*searchIndex) []tokenCorrection {
+	var vocabs []map[string]vocabWord
+	for _, idx := range indexes {
+		if idx != nil {
+			vocabs = append(vocabs, idx.vocabulary())
+		}
+	}
+	var out []tokenCorrection
+	seen := map[string]bool{}
+	for _, token := range tokens {
+		if seen[token] || len(token) < minFuzzyTokenLength || strings.IndexFunc(token, isNotWordRune) >= 0 || inVocabulary(token, vocabs) {
+			continue
+		}
+		seen[token] = true
+		limit := maxFuzzyEdits(len(token))
+		best, bestDist := vocabWord{}, limit+1
+		bestLower := ""
+		for _, vocab := range vocabs {
+			for lower, word := range vocab {
+				if d := len(lower) - len(token); d > limit || -d > limit {
+					continue
+				}
+				dist := levenshtein(token, lower)
+				if dist < bestDist || dist == bestDist && (word.count > best.count || word.count == best.count && lower < bestLower) {
+					best, bestDist, bestLower = word, dist, lower
+				}
+			}
+		}
+		if bestLower != "" {
+			out = append(out, tokenCorrection{From: token, To: bestLower, Display: best.form})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].From < out[j].From })
+	return out
+}
+
+func inVocabulary(token string, vocabs []map[string]vocabWord) bool {
+	for _, vocab := range vocabs {
+		if _, ok := vocab[token]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// applyCorrections returns tokens with every corrected token replaced.
+func applyCorrections(tokens []string, corrections []tokenCorrection) []string {
+	if len(corrections) == 0 {
+		return tokens
+	}
+	out := make([]string, len(tokens))
+	for i, token := range tokens {
+		out[i] = token
+		for _, c := range corrections {
+			if c.From == token {
+				out[i] = c.To
+			}
+		}
+	}
+	return out
+}
+
+// writeCorrectionsLine shows which query tokens the fuzzy stage corrected,
+// so an agent can tell a typo was searched as the corpus spelling.
+func writeCorrectionsLine(out *strings.Builder, corrections []tokenCorrection) {
+	if len(corrections) == 0 {
+		return
+	}
+	parts := make([]string, len(corrections))
+	for i, c := range corrections {
+		parts[i] = fmt.Sprintf("%s → %s", c.From, c.Display)
+	}
+	fmt.Fprintf(out, "Corrected: %s\n", strings.Join(parts, ", "))
+}
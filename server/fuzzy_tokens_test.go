@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestCorrectTokensAgainstCorpusVocabulary(t *testing.T) {
+	idx := &searchIndex{Files: []indexedFile{
+		{Lines: []string{"<DataSource url=\"/api\" />", "<Button onClick=\"save()\" />", "onClick handlers run on click."}},
+	}}
+	got := correctTokens([]string{"datasourse", "onclik", "button", "zzzzzzzz", "url", "$props.value"}, []*searchIndex{idx})
+	want := []tokenCorrection{
+		{From: "datasourse", To: "datasource", Display: "DataSource"},
+		{From: "onclik", To: "onclick", Display: "onClick"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("corrections = %+v, want %+v", got, want)
+	}
+}
+
+func TestFuzzyStageRecordsCorrections(t *testing.T) {
+	resetTopicIndexForTest(t)
+	root := t.TempDir()
+	howtoDir := filepath.Join(root, "howto")
+	if err := os.MkdirAll(howtoDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeHowtoFixture(t, howtoDir, "fetch.md", "# Fetch data\nUse a DataSource to load rows.\n")
+
+	cfg := howtoMediatorConfig(howtoDir)
+	_, summary, err := ExecuteMediatedSearch(root, cfg, "Datasourse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summary.Sections["howtos"]) != 0 {
+		t.Fatalf("typo matched without the fuzzy stage: %+v", summary.Sections)
+	}
+
+	cfg.FuzzyTokens = true
+	human, summary, err := ExecuteMediatedSearch(root, cfg, "Datasourse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summary.Sections["howtos"]) == 0 {
+		t.Fatalf("corrected token found nothing:\n%s", human)
+	}
+	if !reflect.DeepEqual(summary.Tokens["kept"], []string{"datasourse"}) || !reflect.DeepEqual(summary.Tokens["expanded"], []string{"datasource"}) {
+		t.Fatalf("tokens = %v", summary.Tokens)
+	}
+	if !strings.Contains(human, "Corrected: datasourse → DataSource\n") {
+		t.Fatalf("human block lacks the correction:\n%s", human)
+	}
+}
+
+// The search tools leave fuzzy off unless asked: a word missing from the
+// corpus may still be a partial identifier the stages match by substring.
+func TestSearchToolsCorrectOnlyWhenAsked(t *testing.T) {
+	resetTopicIndexForTest(t)
+	ResetSearchCache()
+	t.Cleanup(ResetSearchCache)
+	useTestAnalytics(t)
+	examples := t.TempDir()
+	if err := os.WriteFile(filepath.Join(examples, "fetch.xmlui"), []byte("<DataSource url=\"/api/rows\" />\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, handler := NewExamplesTool([]string{examples})
+
+	for _, fuzzy := range []any{nil, true} {
+		req := searchRequest("Datasourse")
+		if fuzzy != nil {
+			req.Params.Arguments["fuzzy"] = fuzzy
+		}
+		result, err := handler(context.Background(), req)
+		if err != nil || result.IsError {
+			t.Fatalf("fuzzy=%v: %v %+v", fuzzy, err, result)
+		}
+		corrected := strings.Contains(result.Content[0].(mcp.TextContent).Text, "Corrected: datasourse → DataSource")
+		if corrected != (fuzzy != nil) {
+			t.Fatalf("fuzzy=%v: corrected=%v\n%s", fuzzy, corrected, result.Content[0].(mcp.TextContent).Text)
+		}
+	}
+}
@@ -80,8 +80,16 @@ func NewListHowtoTool(xmluiDir string) (mcp.Tool, func(context.Context, mcp.Call
 func NewSearchHowtoTool(xmluiDir string) (mcp.Tool, func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)) {
 	tool := mcp.NewTool(
 		"xmlui_search_howto",
-		mcp.WithDescription("Search for 'How To' entries using a staged search mediator. Returns human-readable matches plus a JSON summary."),
-		mcp.WithString("query", mcp.Required(), mcp.Description("Keyword or phrase to search for.")),
+		mcp.WithDescription("Search for 'How To' entries using a staged search mediator. Returns human-readable matches; pass format 'json' or 'both' for the structured MediatorJSON summary."),
+		mcp.WithString("query", mcp.Required(), mcp.Description("Keyword or phrase to search for."+queryGrammarHint)),
+		withSearchFormat(),
+		withSearchOffset(),
+		withSearchCursor(),
+		withSearchContextLines(),
+		withSearchFuzzy(),
+		withSearchExplain(),
+		withSearchHighlight(),
+		withSearchGranularity(),
 	)
 
 	tool.Annotations = mcp.ToolAnnotation{
@@ -97,6 +105,30 @@ func NewSearchHowtoTool(xmluiDir string) (mcp.Tool, func(context.Context, mcp.Ca
 			return mcp.NewToolResultError("Missing or invalid 'query' parameter"), nil
 		}
 		query := strings.TrimSpace(raw)
+		format, err := searchFormatArgument(req)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		contextLines, err := searchContextLinesArgument(req)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		fuzzy, err := searchBoolArgument(req, "fuzzy", false)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		explain, err := searchBoolArgument(req, "explain", false)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		highlight, err := searchBoolArgument(req, "highlight", false)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		granularity, err := searchGranularityArgument(req)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
 
 		// Howto search roots
 		paths := GetRepoPaths(xmluiDir)
@@ -104,24 +136,36 @@ func NewSearchHowtoTool(xmluiDir string) (mcp.Tool, func(context.Context, mcp.Ca
 			filepath.Join(xmluiDir, paths.Howto),
 		}
 
+		dict := CurrentSearchDictionary()
 		cfg := MediatorConfig{
 			Roots:                 roots,
 			SectionKeys:           []string{"howtos"},
 			PreferSections:        []string{"howtos"}, // bias towards howtos (though all are howtos)
 			MaxResults:            50,
 			FileExtensions:        []string{".md", ".mdx"},
-			Stopwords:             DefaultStopwords(),
-			Synonyms:              DefaultSynonyms(),
+			Stopwords:             dict.Stopwords,
+			Synonyms:              dict.Synonyms,
 			Classifier:            HowtoClassifier(xmluiDir),
 			EnableFilenameMatches: true,
 			ToolName:              "xmlui_search_howto",
+			ContextLines:          contextLines,
+			Timeout:               SearchTimeout(),
+			Scorer:                SearchScorer(),
+			FuzzyTokens:           fuzzy,
+			Explain:               explain,
+			Highlight:             highlight,
+			Granularity:           granularity,
+		}
+		cfg.Offset, err = searchPagingArgument(req, cfg, query)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
 		}
 
-		human, err := ExecuteMediatedSearchWithAnalytics(ctx, "xmlui_search_howto", xmluiDir, cfg, query)
+		human, summary, err := ExecuteMediatedSearchWithAnalytics(ctx, "xmlui_search_howto", xmluiDir, cfg, query)
 		if err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
-		return mcp.NewToolResultText(human), nil
+		return searchToolResult(format, human, summary), nil
 	}
 
 	return tool, handler
@@ -14,7 +14,7 @@ import (
 func NewListComponentsTool(homeDir string) (mcp.Tool, func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)) {
 
 	tool := mcp.NewTool("xmlui_list_components",
-		mcp.WithDescription("Lists all available XMLUI components."),
+		mcp.WithDescription("Lists all available XMLUI components. A variant is shown with the component it derives from, e.g. 'VStack ← Stack': its docs may be thin, and the parent's props and events apply."),
 	)
 
 	tool.Annotations = mcp.ToolAnnotation{
@@ -98,10 +98,14 @@ func NewListComponentsTool(homeDir string) (mcp.Tool, func(context.Context, mcp.
 		}
 		sort.Strings(groupNames)
 
+		families := componentFamiliesFor(ctx, homeDir)
 		for _, group := range groupNames {
 			out.WriteString(fmt.Sprintf("## %s\n\n", group))
 			for _, c := range groups[group] {
 				name := filepath.Base(c)
+				if parent := families.parent(name); parent != "" {
+					name += " ← " + parent
+				}
 				out.WriteString(fmt.Sprintf("- %s → call xmlui_component_docs with component: \"%s\"\n", name, c))
 			}
 			out.WriteString("\n")
@@ -0,0 +1,474 @@
+package server
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SearchModeMarkup runs the query as a structural selector over parsed
+// .xmlui files; see parseMarkupSelector.
+const SearchModeMarkup = "markup"
+
+const (
+	defaultMarkupMatches = 200
+	// maxMarkupContextLines caps the source shown for one enclosing
+	// element; the range itself is always reported in full.
+	maxMarkupContextLines = 60
+)
+
+// markupElement is one element of a parsed .xmlui file, with its 1-based
+// source line range and the byte range of its start tag in the joined
+// source.
+type markupElement struct {
+	Name      string
+	Attrs     map[string]string
+	Parent    *markupElement
+	StartLine int
+	EndLine   int
+	Offset    int
+	TagEnd    int
+}
+
+// markupDoc is a parsed .xmlui file: its elements in document order, and
+// the first parse error, if the file is not well-formed. Elements parsed
+// before an error are kept; any left open end at the error.
+type markupDoc struct {
+	Elements []*markupElement
+	Err      error
+}
+
+// markupDocs parses the index's .xmlui files, keyed by absolute path. Like
+// vocabulary, it is built on first use and lives as long as the index does.
+func (idx *searchIndex) markupDocs() map[string]*markupDoc {
+	idx.markupOnce.Do(func() {
+		idx.markup = make(map[string]*markupDoc)
+		for i := range idx.Files {
+			file := &idx.Files[i]
+			if hasAllowedExt(file.Path, []string{".xmlui"}) {
+				idx.markup[file.Path] = parseMarkup(file.Lines)
+			}
+		}
+	})
+	return idx.markup
+}
+
+// parseMarkup parses XMLUI markup leniently: expressions such as
+// "{a && b}" or "{count < 5}" are not valid XML, so entities and bare
+// ampersands are tolerated the way a browser would, and stray "<"s are
+// escaped first (see escapeMarkupSource).
+func parseMarkup(lines []string) *markupDoc {
+	src := strings.Join(lines, "\n")
+	escaped, escapes := escapeMarkupSource(src)
+	// original maps an offset in escaped back to src: each escape
+	// before it added three bytes.
+	original := func(offset int64) int {
+		n := sort.SearchInts(escapes, int(offset))
+		return min(int(offset)-3*n, len(src))
+	}
+	lineAt := func(offset int64) int {
+		return strings.Count(src[:original(offset)], "\n") + 1
+	}
+	dec := xml.NewDecoder(strings.NewReader(escaped))
+	dec.Strict = false
+	dec.AutoClose = nil
+	dec.Entity = xml.HTMLEntity
+
+	doc := &markupDoc{}
+	var open *markupElement
+	for {
+		start := dec.InputOffset()
+		tok, err := dec.Token()
+		if err != nil {
+			if err != io.EOF {
+				doc.Err = err
+			}
+			break
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			el := &markupElement{Name: markupName(t.Name), Attrs: make(map[string]string, len(t.Attr)), Parent: open, StartLine: lineAt(start), Offset: original(start), TagEnd: original(dec.InputOffset())}
+			for _, a := range t.Attr {
+				el.Attrs[markupName(a.Name)] = a.Value
+			}
+			doc.Elements = append(doc.Elements, el)
+			open = el
+		case xml.EndElement:
+			if open != nil {
+				open.EndLine = lineAt(dec.InputOffset() - 1)
+				open = open.Parent
+			}
+		}
+	}
+	for el := open; el != nil; el = el.Parent {
+		el.EndLine = len(lines)
+	}
+	return doc
+}
+
+// escapeMarkupSource replaces the "<"s XMLUI allows but XML does not with
+// "&lt;": inside quoted attribute values, throughout <script> bodies, and
+// in text where no tag name follows. It returns the escaped source and the
+// escaped offsets of the replacements, ascending.
+func escapeMarkupSource(src string) (string, []int) {
+	var out strings.Builder
+	var escapes []int
+	escape := func() {
+		escapes = append(escapes, out.Len())
+		out.WriteString("&lt;")
+	}
+	inTag, inScript, scriptTag := false, false, false
+	var quote byte
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '<' {
+				escape()
+				continue
+			}
+		case inTag:
+			if c == '"' || c == '\'' {
+				quote = c
+			} else if c == '>' {
+				inTag = false
+				inScript = scriptTag && src[i-1] != '/'
+			}
+		case c != '<':
+		case inScript && !strings.HasPrefix(src[i:], "</script"):
+			escape()
+			continue
+		case strings.HasPrefix(src[i:], "<!--"):
+			end := strings.Index(src[i:], "-->")
+			if end < 0 {
+				end = len(src) - i - 3
+			}
+			out.WriteString(src[i : i+end+3])
+			i += end + 2
+			continue
+		case i+1 < len(src) && (isMarkupNameStart(src[i+1]) || src[i+1] == '/' || src[i+1] == '!' || src[i+1] == '?'):
+			inTag, inScript = true, false
+			scriptTag = strings.HasPrefix(src[i:], "<script") && (i+7 == len(src) || !isMarkupNameStart(src[i+7]))
+		default:
+			escape()
+			continue
+		}
+		out.WriteByte(c)
+	}
+	return out.String(), escapes
+}
+
+func isMarkupNameStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func markupName(n xml.Name) string {
+	if n.Space != "" {
+		return n.Space + ":" + n.Local
+	}
+	return n.Local
+}
+
+// markupCompound is one step of a selector: an element name ("" or "*"
+// for any) and attribute tests, joined to the step before it by a child
+// (">") or descendant (whitespace) combinator.
+type markupCompound struct {
+	Name  string
+	Attrs []markupAttrTest
+	Child bool // joined to the previous step by ">"
+}
+
+// markupAttrTest is "[name]", "[name=value]", "[name*=value]",
+// "[name^=value]" or "[name$=value]".
+type markupAttrTest struct {
+	Name, Op, Value string
+}
+
+var (
+	markupNameRe = regexp.MustCompile(`^(\*|[A-Za-z_][\w.:-]*)`)
+	markupAttrRe = regexp.MustCompile(`^\[\s*([A-Za-z_][\w.:-]*)\s*(?:([*^$]?=)\s*("[^"]*"|'[^']*'|[^\]\s]+)\s*)?\]`)
+)
+
+// parseMarkupSelector parses a CSS-like selector over XMLUI elements, e.g.
+// "Table > Column[canSort]", "Form TextBox[required=true]" or
+// "*[onClick*=navigate]". Element and attribute names match
+// case-insensitively; values match case-sensitively.
+func parseMarkupSelector(selector string) ([]markupCompound, error) {
+	s := strings.TrimSpace(selector)
+	var steps []markupCompound
+	child := false
+	for s != "" {
+		var step markupCompound
+		if m := markupNameRe.FindString(s); m != "" {
+			step.Name = m
+			s = s[len(m):]
+		}
+		for {
+			m := markupAttrRe.FindStringSubmatch(s)
+			if m == nil {
+				break
+			}
+			step.Attrs = append(step.Attrs, markupAttrTest{Name: m[1], Op: m[2], Value: strings.Trim(m[3], `"'`)})
+			s = s[len(m[0]):]
+		}
+		if step.Name == "" && len(step.Attrs) == 0 {
+			return nil, fmt.Errorf("Invalid markup selector %q: expected an element name or [attribute] at %q", selector, s)
+		}
+		step.Child = child
+		steps = append(steps, step)
+
+		rest := strings.TrimLeft(s, " \t\n")
+		child = strings.HasPrefix(rest, ">")
+		if child {
+			rest = strings.TrimLeft(rest[1:], " \t\n")
+		} else if rest != "" && rest == s {
+			return nil, fmt.Errorf("Invalid markup selector %q: unexpected %q", selector, rest)
+		}
+		if child && rest == "" {
+			return nil, fmt.Errorf("Invalid markup selector %q: '>' needs an element after it", selector)
+		}
+		s = rest
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("Invalid markup selector %q: empty selector", selector)
+	}
+	return steps, nil
+}
+
+func (c markupCompound) matches(el *markupElement) bool {
+	if c.Name != "" && c.Name != "*" && !strings.EqualFold(c.Name, el.Name) {
+		return false
+	}
+	for _, test := range c.Attrs {
+		value, ok := "", false
+		for name, v := range el.Attrs {
+			if strings.EqualFold(name, test.Name) {
+				value, ok = v, true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+		switch test.Op {
+		case "=":
+			ok = value == test.Value
+		case "*=":
+			ok = strings.Contains(value, test.Value)
+		case "^=":
+			ok = strings.HasPrefix(value, test.Value)
+		case "$=":
+			ok = strings.HasSuffix(value, test.Value)
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// matchMarkupSelector reports whether el matches the selector's last step
+// with ancestors satisfying the earlier ones, and returns the element that
+// matched the first step: the enclosing element the result reports.
+func matchMarkupSelector(steps []markupCompound, el *markupElement) (*markupElement, bool) {
+	last := len(steps) - 1
+	if !steps[last].matches(el) {
+		return nil, false
+	}
+	if last == 0 {
+		return el, true
+	}
+	rest := steps[:last]
+	for anc := el.Parent; anc != nil; anc = anc.Parent {
+		if outer, ok := matchMarkupSelector(rest, anc); ok {
+			return outer, true
+		}
+		if steps[last].Child {
+			break
+		}
+	}
+	return nil, false
+}
+
+// executeMarkupSearch is ExecuteMediatedSearch in SearchModeMarkup: every
+// element of every .xmlui file under the roots is tested against the
+// selector, in walk and document order, up to defaultMarkupMatches. Each
+// result is the enclosing element (the one the selector's first step
+// matched) with its source range; Line is the first matched element in it.
+func executeMarkupSearch(ctx context.Context, homeDir string, cfg MediatorConfig, selector string, indexSet *searchIndexSet) (string, MediatorJSON, error) {
+	steps, err := parseMarkupSelector(selector)
+	if err != nil {
+		return "", MediatorJSON{}, err
+	}
+	filter, err := newSearchFilter(cfg.Sections, cfg.Include, cfg.Exclude)
+	if err != nil {
+		return "", MediatorJSON{}, err
+	}
+	cfg.Roots = filter.pruneRoots(homeDir, cfg.Roots)
+
+	jsonOut := MediatorJSON{
+		QueryPlan:      []stageHit{},
+		Tokens:         map[string][]string{"kept": {}, "removed": {}, "expanded": {}},
+		Sections:       make(map[string][]resultItem),
+		Facets:         make(map[string]FacetCounts),
+		RelatedQueries: []string{},
+		Diagnostics: map[string]any{
+			"original_query": selector,
+			"mode":           SearchModeMarkup,
+			"match_budget":   defaultMarkupMatches,
+		},
+	}
+	for _, k := range cfg.SectionKeys {
+		jsonOut.Sections[k] = []resultItem{}
+	}
+
+	type markupFile struct {
+		rel, section string
+		items        []resultItem
+		matches      int
+	}
+	var files []*markupFile
+	var malformed []string
+	seen := map[string]bool{}
+	matches := 0
+	truncated := ""
+	indexes := indexSet.resolve(ctx, cfg.Roots)
+
+scan:
+	for _, idx := range indexes {
+		if idx == nil {
+			truncated = searchTruncation(ctx)
+			break
+		}
+		docs := idx.markupDocs()
+		for i := range idx.Files {
+			if ctx.Err() != nil {
+				truncated = searchTruncation(ctx)
+				break scan
+			}
+			file := &idx.Files[i]
+			doc := docs[file.Path]
+			if doc == nil || seen[file.Path] {
+				continue
+			}
+			seen[file.Path] = true
+			rel := toRepoRelative(homeDir, file.Path)
+			section := cfg.Classifier(rel, file.Path)
+			if !filter.allowsFile(rel, section) {
+				continue
+			}
+			if doc.Err != nil {
+				malformed = append(malformed, rel)
+			}
+			var current *markupFile
+			byOuter := map[*markupElement]int{}
+			for _, el := range doc.Elements {
+				outer, ok := matchMarkupSelector(steps, el)
+				if !ok {
+					continue
+				}
+				if matches == defaultMarkupMatches {
+					truncated = "match_budget"
+					break scan
+				}
+				matches++
+				if current == nil {
+					current = &markupFile{rel: rel, section: section}
+					files = append(files, current)
+				}
+				current.matches++
+				if _, dup := byOuter[outer]; dup {
+					continue
+				}
+				byOuter[outer] = len(current.items)
+				current.items = append(current.items, markupResultItem(file, rel, section, outer, el, cfg.MaxSnippetLength))
+			}
+		}
+	}
+
+	for _, f := range files {
+		facet := jsonOut.Facets[f.section]
+		facet.Files++
+		facet.Matches += f.matches
+		jsonOut.Facets[f.section] = facet
+		jsonOut.Sections[f.section] = append(jsonOut.Sections[f.section], f.items...)
+	}
+	jsonOut.QueryPlan = append(jsonOut.QueryPlan, stageHit{Stage: SearchModeMarkup, Query: selector, Hits: matches})
+	jsonOut.Diagnostics["truncated"] = truncated != ""
+	if truncated != "" {
+		jsonOut.Diagnostics["truncated_reason"] = truncated
+	}
+	if len(malformed) > 0 {
+		sort.Strings(malformed)
+		jsonOut.Diagnostics["malformed_files"] = malformed
+	}
+	if matches > 0 {
+		jsonOut.Confidence = "high"
+	} else {
+		jsonOut.Confidence = "low"
+		jsonOut.AgentGuidance = &AgentGuidance{
+			RuleReminders:     []string{},
+			SuggestedApproach: "No element matched. Selectors read like CSS: 'Table > Column' for a direct child, 'Form TextBox' for any descendant, '[canSort]' for an attribute, '[label=\"Name\"]', '[onClick*=navigate]' for values. Drop a step or an attribute test to widen the match.",
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "Markup: %s  (files=%d, matches=%d)\n", selector, len(files), matches)
+	if truncated == "match_budget" {
+		fmt.Fprintf(&out, "Stopped at the %d-match budget: add a step or attribute test to the selector to see the rest.\n", defaultMarkupMatches)
+	} else {
+		writeTruncatedLine(&out, truncated)
+	}
+	if len(malformed) > 0 {
+		fmt.Fprintf(&out, "Not well-formed (matched up to the first error): %s\n", strings.Join(malformed, ", "))
+	}
+	out.WriteString("\n")
+	if len(files) == 0 {
+		out.WriteString("No matches found.\n")
+	}
+	for _, f := range files {
+		fmt.Fprintf(&out, "## %s  (matches=%d, section=%s)\n", f.rel, f.matches, f.section)
+		for _, item := range f.items {
+			writeResultItem(&out, item, nil)
+			if more := item.EndLine - item.StartLine + 1 - len(item.Context); more > 0 {
+				fmt.Fprintf(&out, "    ... (%d more lines)\n", more)
+			}
+		}
+		out.WriteString("\n")
+	}
+	writeGuidanceBlock(&out, jsonOut)
+	return out.String(), jsonOut, nil
+}
+
+// markupResultItem reports outer's source range, with up to
+// maxMarkupContextLines of it, and the matched element el's start tag line.
+func markupResultItem(file *indexedFile, rel, section string, outer, el *markupElement, maxLen int) resultItem {
+	clip := func(line string) string {
+		if len(line) > maxLen {
+			return line[:maxLen] + "..."
+		}
+		return line
+	}
+	item := resultItem{
+		Type:      section,
+		Path:      rel,
+		AbsPath:   file.Path,
+		Line:      el.StartLine,
+		StartLine: outer.StartLine,
+		EndLine:   outer.EndLine,
+	}
+	if el.StartLine <= len(file.Lines) {
+		item.Snippet = clip(file.Lines[el.StartLine-1])
+	}
+	last := min(outer.EndLine, outer.StartLine+maxMarkupContextLines-1, len(file.Lines))
+	for _, line := range file.Lines[outer.StartLine-1 : last] {
+		item.Context = append(item.Context, clip(line))
+	}
+	return item
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const markupTableApp = `<App>
+  <Form>
+    <TextBox id="name" required="true" />
+  </Form>
+  <Table data="/api/users">
+    <Column bindTo="name" canSort="true" />
+    <Column bindTo="email" />
+  </Table>
+  <VStack>
+    <Table data="/api/orders">
+      <Column bindTo="total" canSort />
+    </Table>
+  </VStack>
+</App>
+`
+
+func TestParseMarkupSelector(t *testing.T) {
+	steps, err := parseMarkupSelector(`Table > Column[canSort][bindTo^="na"]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 2 || steps[0].Name != "Table" || steps[1].Name != "Column" || !steps[1].Child {
+		t.Fatalf("steps = %+v", steps)
+	}
+	if got := steps[1].Attrs; len(got) != 2 || got[0] != (markupAttrTest{Name: "canSort"}) || got[1] != (markupAttrTest{Name: "bindTo", Op: "^=", Value: "na"}) {
+		t.Fatalf("attrs = %+v", got)
+	}
+	for _, bad := range []string{"", "Table >", "Table ! Column", "[=x]"} {
+		if _, err := parseMarkupSelector(bad); err == nil || !strings.Contains(err.Error(), "Invalid markup selector") {
+			t.Fatalf("%q: err = %v", bad, err)
+		}
+	}
+}
+
+func TestMarkupSelectorMatchesStructure(t *testing.T) {
+	doc := parseMarkup(strings.Split(markupTableApp, "\n"))
+	if doc.Err != nil {
+		t.Fatal(doc.Err)
+	}
+	count := func(selector string) (n int, outers []int) {
+		steps, err := parseMarkupSelector(selector)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, el := range doc.Elements {
+			if outer, ok := matchMarkupSelector(steps, el); ok {
+				n++
+				outers = append(outers, outer.StartLine)
+			}
+		}
+		return n, outers
+	}
+	if n, outers := count("Table > Column[canSort]"); n != 2 || outers[0] != 5 || outers[1] != 10 {
+		t.Fatalf("Table > Column[canSort]: %d %v", n, outers)
+	}
+	if n, _ := count("App > Column"); n != 0 {
+		t.Fatalf("App > Column matched %d grandchildren", n)
+	}
+	if n, outers := count("app column[bindto=email]"); n != 1 || outers[0] != 1 {
+		t.Fatalf("descendant, case-insensitive names: %d %v", n, outers)
+	}
+	if n, _ := count("[bindTo=Name]"); n != 0 {
+		t.Fatal("attribute values must match case-sensitively")
+	}
+	if n, _ := count("*[data$=orders]"); n != 1 {
+		t.Fatalf("suffix match: %d", n)
+	}
+
+	table := doc.Elements[3]
+	if table.Name != "Table" || table.StartLine != 5 || table.EndLine != 8 {
+		t.Fatalf("table = %+v", table)
+	}
+}
+
+func TestExamplesMarkupModeReturnsEnclosingElement(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "Main.xmlui"), []byte(markupTableApp), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "Broken.xmlui"), []byte("<App>\n  <Table>\n    <Column canSort />\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, handler := NewExamplesTool([]string{root})
+
+	var req mcp.CallToolRequest
+	req.Params.Arguments = map[string]interface{}{"query": "Table > Column[canSort]", "mode": "markup"}
+	result, err := handler(context.Background(), req)
+	if err != nil || result.IsError {
+		t.Fatalf("unexpected error: %v %+v", err, result)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	for _, want := range []string{
+		"Markup: Table > Column[canSort]  (files=2, matches=3)",
+		"Not well-formed (matched up to the first error): ",
+		"  L5-8:\n    5|   <Table data=\"/api/users\">\n    6|     <Column bindTo=\"name\" canSort=\"true\" />",
+		"  L10-12:\n",
+		"  L2-3:\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("missing %q in:\n%s", want, text)
+		}
+	}
+
+	req.Params.Arguments = map[string]interface{}{"query": "Table >", "mode": "markup"}
+	if result, _ := handler(context.Background(), req); !result.IsError {
+		t.Fatal("an invalid selector was accepted")
+	}
+	req.Params.Arguments = map[string]interface{}{"query": "Table", "mode": "markup", "offset": float64(10)}
+	if result, _ := handler(context.Background(), req); !result.IsError {
+		t.Fatal("markup mode accepted an offset")
+	}
+}
+
+func TestEscapeMarkupSourceKeepsExpressionsParseable(t *testing.T) {
+	src := "<App when=\"{count < 5}\">\n  <Text>{a < b}</Text>\n  <script>if (a<b) { go() }</script>\n  <!-- <Old/> -->\n</App>"
+	doc := parseMarkup(strings.Split(src, "\n"))
+	if doc.Err != nil {
+		t.Fatal(doc.Err)
+	}
+	if len(doc.Elements) != 3 || doc.Elements[0].Attrs["when"] != "{count < 5}" {
+		t.Fatalf("elements = %+v", doc.Elements)
+	}
+	text := doc.Elements[1]
+	if text.Name != "Text" || text.StartLine != 2 || src[text.Offset:text.TagEnd] != "<Text>" {
+		t.Fatalf("offsets are not in the original source: %+v", text)
+	}
+}
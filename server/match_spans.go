@@ -0,0 +1,119 @@
+package server
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// withSearchHighlight adds the shared "highlight" argument.
+func withSearchHighlight() mcp.ToolOption {
+	return mcp.WithBoolean("highlight",
+		mcp.Description("Optional, default false. Wraps the query terms matched in each snippet of the human block in **bold**. The JSON summary always carries the match offsets, as each item's spans."),
+	)
+}
+
+// MatchSpan is one query-term match in a result snippet: byte offsets
+// [Start,End) into resultItem.Snippet and the query term it counts toward.
+type MatchSpan struct {
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Term  string `json:"term"`
+}
+
+// matchSpans finds the query terms in text on the same basis coverage
+// counts them (hitCoversTerm, addFileHit): case-insensitive occurrences of
+// a term's stem, widened to the whole term where the text spells it out, and
+// of its synonyms. Overlaps resolve to the earlier, then the longer, span.
+func matchSpans(text string, terms []string, synonyms map[string][]string) []MatchSpan {
+	lower := foldCaseSameLength(text)
+	var spans []MatchSpan
+	seen := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		if term == "" || seen[term] {
+			continue
+		}
+		seen[term] = true
+		stem := termStem(term)
+		for from := 0; ; {
+			i := strings.Index(lower[from:], stem)
+			if i < 0 {
+				break
+			}
+			start := from + i
+			end := start + len(stem)
+			if strings.HasPrefix(lower[start:], term) {
+				end = start + len(term)
+			}
+			spans = append(spans, MatchSpan{Start: start, End: end, Term: term})
+			from = end
+		}
+		for _, alt := range synonyms[term] {
+			for from := 0; alt != ""; {
+				i := strings.Index(lower[from:], alt)
+				if i < 0 {
+					break
+				}
+				start := from + i
+				spans = append(spans, MatchSpan{Start: start, End: start + len(alt), Term: term})
+				from = start + len(alt)
+			}
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool {
+		if spans[i].Start == spans[j].Start {
+			return spans[i].End > spans[j].End
+		}
+		return spans[i].Start < spans[j].Start
+	})
+	out := spans[:0]
+	for _, s := range spans {
+		if len(out) > 0 && s.Start < out[len(out)-1].End {
+			continue
+		}
+		out = append(out, s)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// foldCaseSameLength lowercases s rune by rune, leaving any rune whose
+// lowercase form has a different UTF-8 length as is, so byte offsets into
+// the result are byte offsets into s. Invalid bytes are copied through.
+func foldCaseSameLength(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if l := unicode.ToLower(r); r != utf8.RuneError && utf8.RuneLen(l) == size {
+			b.WriteRune(l)
+		} else {
+			b.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	return b.String()
+}
+
+// highlightSpans wraps each span of text in **…**.
+func highlightSpans(text string, spans []MatchSpan) string {
+	if len(spans) == 0 {
+		return text
+	}
+	var b strings.Builder
+	last := 0
+	for _, s := range spans {
+		b.WriteString(text[last:s.Start])
+		b.WriteString("**")
+		b.WriteString(text[s.Start:s.End])
+		b.WriteString("**")
+		last = s.End
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMatchSpansUseStemsAndSynonyms(t *testing.T) {
+	text := "Ärger: a Deep Link to the Table; linking rows."
+	got := matchSpans(text, []string{"linking", "grid", "linking"}, map[string][]string{"grid": {"table"}})
+	link := strings.Index(text, "Link")
+	table := strings.Index(text, "Table")
+	linking := strings.Index(text, "linking")
+	want := []MatchSpan{
+		{Start: link, End: link + 4, Term: "linking"},
+		{Start: table, End: table + 5, Term: "grid"},
+		{Start: linking, End: linking + 7, Term: "linking"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("spans = %+v\nwant %+v", got, want)
+	}
+	if marked := highlightSpans(text, got); marked != "Ärger: a Deep **Link** to the **Table**; **linking** rows." {
+		t.Fatalf("highlighted = %q", marked)
+	}
+	if matchSpans("nothing here", []string{"table"}, nil) != nil {
+		t.Fatal("expected no spans")
+	}
+}
+
+func TestSnippetSpansAgreeWithTermCoverage(t *testing.T) {
+	resetTopicIndexForTest(t)
+	root := t.TempDir()
+	howtoDir := filepath.Join(root, "howto")
+	if err := os.MkdirAll(howtoDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeHowtoFixture(t, howtoDir, "nav.md", "# Navigation\nDeep linking: add a deep link to a page.\n")
+
+	cfg := howtoMediatorConfig(howtoDir)
+	cfg.Highlight = true
+	human, summary, err := ExecuteMediatedSearch(root, cfg, "deep linking")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var spans []MatchSpan
+	for _, item := range summary.Sections["howtos"] {
+		if item.Line == 2 {
+			spans = item.Spans
+		}
+	}
+	want := []MatchSpan{
+		{Start: 0, End: 4, Term: "deep"},
+		{Start: 5, End: 12, Term: "linking"},
+		{Start: 20, End: 24, Term: "deep"},
+		{Start: 25, End: 29, Term: "linking"},
+	}
+	if !reflect.DeepEqual(spans, want) {
+		t.Fatalf("spans = %+v, want %+v", spans, want)
+	}
+	for _, entry := range summary.Salience.TermCoverage {
+		if entry.ContentMatches == 0 {
+			t.Fatalf("coverage disagrees with spans: %+v", summary.Salience.TermCoverage)
+		}
+	}
+	if !strings.Contains(human, "L2: **Deep** **linking**: add a **deep** **link** to a page.") {
+		t.Fatalf("human block lacks highlighting:\n%s", human)
+	}
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,6 +10,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 	"unicode"
 )
 
@@ -40,9 +42,17 @@ type MediatorConfig struct {
 	// Max files to return after ranking (default 15)
 	MaxFileResults int
 
+	// Ranked files to skip before the returned page (default 0). When more
+	// files remain, MediatorJSON.NextCursor encodes the following offset.
+	Offset int
+
 	// Max snippets per file in output (default 3)
 	MaxSnippetsPerFile int
 
+	// Lines of context around each snippet (default 0: the line alone).
+	// Windows merge when they overlap and stop at code-fence lines.
+	ContextLines int
+
 	// File extensions to scan.
 	FileExtensions []string // default: .mdx, .md, .tsx, .scss
 
@@ -56,9 +66,50 @@ type MediatorConfig struct {
 	// rel is relative to homeDir, absPath is the absolute file path.
 	Classifier func(rel string, absPath string) string
 
+	// Optional: restrict results to these section keys and to repo-relative
+	// paths matching Include and none of Exclude (globs, "**" spans dirs).
+	// Roots an Exclude pattern covers entirely are dropped before the scan.
+	Sections []string
+	Include  []string
+	Exclude  []string
+
+	// Optional: SearchModeRegex runs the query as a Go regexp over the same
+	// roots and extensions instead of the staged word search;
+	// SearchModeMarkup runs it as a selector over the roots' .xmlui files.
+	// Empty selects the staged search.
+	Mode string
+
+	// Max regex matches collected before the scan stops (default 200).
+	MaxRegexMatches int
+
+	// Optional: time budget for the scan. When it runs out, ranking proceeds
+	// over the files collected so far and Diagnostics["truncated"] is true.
+	// Zero leaves only the caller's context to bound the search.
+	Timeout time.Duration
+
+	// Optional: SearchGranularitySection ranks the H2/H3 sections of Markdown
+	// files instead of whole files. Empty or SearchGranularityFile ranks files.
+	Granularity string
+
+	// Optional: correct kept query tokens that occur nowhere in the roots to
+	// the nearest corpus word (by edit distance) before matching.
+	FuzzyTokens bool
+
+	// Optional: record each ranked file's score breakdown, and the scorer's
+	// per-query decisions, in Diagnostics["explain"].
+	Explain bool
+
+	// Optional: wrap matched query terms in **…** in the human block's
+	// snippets. Match offsets are in the JSON regardless.
+	Highlight bool
+
 	// Optional: enable filename matches (per your legacy behavior). Default true.
 	EnableFilenameMatches bool
 
+	// Optional: ranks the collected files. If nil, DefaultScorer() is used.
+	// The scorer's name is recorded in the JSON diagnostics.
+	Scorer Scorer
+
 	// Optional: the MCP tool name this search serves (e.g. "xmlui_search_howto").
 	// Guidance excludes the producing tool from pivot suggestions (#23 polish).
 	ToolName string
@@ -129,6 +180,7 @@ type MediatorJSON struct {
 	TopicMatches        []string                `json:"topic_matches,omitempty"`
 	Suggestions         []string                `json:"suggestions,omitempty"`
 	OutOfScopePointers  []DocumentationURL      `json:"out_of_scope_pointers,omitempty"`
+	NextCursor          string                  `json:"next_cursor,omitempty"`
 }
 
 // ExecuteMediatedSearch runs the staged scan and returns:
@@ -145,12 +197,20 @@ type scoredFile struct {
 	Section    string
 	Score      float64
 	Snippets   []scoredSnippet
-	Deprecated      bool   // true if file contains a [!WARNING] deprecation notice
+	Deprecated      bool   // true if the deprecation registry lists the file
 	ReplacementText string // e.g. "global variables"
 	ReplacementLink string // e.g. "/guides/markup#global-variables"
 	TitleMatch      bool   // filename contains a query term (the scoring bonus fired)
 	// tracking which query terms were found in this file
 	TermsFound map[string]bool
+
+	// Set on section results (Granularity "section"): the section's 1-based
+	// inclusive line range, heading and anchor. Zero for whole files.
+	StartLine, EndLine int
+	Heading, Anchor    string
+
+	// Set by the scorer when MediatorConfig.Explain is on.
+	Explain *ScoreExplanation
 }
 
 type scoredSnippet struct {
@@ -161,6 +221,31 @@ type scoredSnippet struct {
 }
 
 func ExecuteMediatedSearch(homeDir string, cfg MediatorConfig, originalQuery string) (string, MediatorJSON, error) {
+	return ExecuteMediatedSearchContext(context.Background(), homeDir, cfg, originalQuery)
+}
+
+// ExecuteMediatedSearchContext is ExecuteMediatedSearch bounded by ctx and
+// cfg.Timeout. When either ends the scan early, the files collected so far
+// are still ranked and returned, with Diagnostics["truncated"] set.
+func ExecuteMediatedSearchContext(ctx context.Context, homeDir string, cfg MediatorConfig, originalQuery string) (string, MediatorJSON, error) {
+	res, err := executeMediatedSearch(ctx, homeDir, cfg, originalQuery, nil)
+	return res.human, res.summary, err
+}
+
+// mediatedResult is one search's output plus what a cached copy needs to
+// bring its related queries up to date: they lead with analytics
+// reformulations, which change as the log grows, while the topic headings
+// after them depend only on the corpus and the query.
+type mediatedResult struct {
+	human     string
+	summary   MediatorJSON
+	headings  []string // the topic-heading half of summary.RelatedQueries
+	relatedAt int      // offset of the related-queries line in human, or -1
+}
+
+// executeMediatedSearch is ExecuteMediatedSearchContext over indexSet, which
+// may already hold roots the caller resolved; nil resolves every root here.
+func executeMediatedSearch(ctx context.Context, homeDir string, cfg MediatorConfig, originalQuery string, indexSet *searchIndexSet) (mediatedResult, error) {
 	// defaults
 	if cfg.MaxResults <= 0 {
 		cfg.MaxResults = 50
@@ -174,6 +259,9 @@ func ExecuteMediatedSearch(homeDir string, cfg MediatorConfig, originalQuery str
 	if cfg.MaxSnippetsPerFile <= 0 {
 		cfg.MaxSnippetsPerFile = 3
 	}
+	if cfg.Offset < 0 {
+		cfg.Offset = 0
+	}
 	if len(cfg.FileExtensions) == 0 {
 		cfg.FileExtensions = []string{".mdx", ".md", ".tsx", ".scss"}
 	}
@@ -188,32 +276,116 @@ func ExecuteMediatedSearch(homeDir string, cfg MediatorConfig, originalQuery str
 	if len(cfg.SectionKeys) == 0 {
 		cfg.SectionKeys = []string{"components", "howtos", "examples", "source"}
 	}
+	if cfg.Scorer == nil {
+		cfg.Scorer = DefaultScorer()
+	}
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+	}
+	if indexSet == nil {
+		indexSet = newSearchIndexSet(homeDir, cfg.FileExtensions)
+	}
+	if cfg.Mode == SearchModeRegex || cfg.Mode == SearchModeMarkup {
+		execute := executeRegexSearch
+		if cfg.Mode == SearchModeMarkup {
+			execute = executeMarkupSearch
+		}
+		human, summary, err := execute(ctx, homeDir, cfg, originalQuery, indexSet)
+		return mediatedResult{human: human, summary: summary, relatedAt: -1}, err
+	}
+	parsed := parseQuery(originalQuery)
+	branches := parsed.branchTexts(originalQuery)
+	filter, err := newSearchFilter(cfg.Sections, cfg.Include, cfg.Exclude)
+	if err != nil {
+		return mediatedResult{relatedAt: -1}, err
+	}
+	filter = filter.withQueryFields(parsed)
+	cfg.Roots = filter.pruneRoots(homeDir, cfg.Roots)
 
 	// Prepare accumulators
 	fileScores := make(map[string]*scoredFile) // keyed by absPath
 
 	jsonOut := MediatorJSON{
-		QueryPlan: []stageHit{},
+		QueryPlan: []stageHit{{Stage: "parse", Query: parsed.Interpreted, Parsed: &parsed}},
 		Tokens:    map[string][]string{"kept": {}, "removed": {}, "expanded": {}},
 		Sections:  make(map[string][]resultItem),
 		Facets:    make(map[string]FacetCounts),
 		Diagnostics: map[string]any{
 			"original_query": strings.TrimSpace(originalQuery),
+			"scorer":         cfg.Scorer.Name(),
 		},
 	}
+	if filter != nil {
+		jsonOut.Diagnostics["filters"] = map[string][]string{
+			"sections": cfg.Sections,
+			"include":  cfg.Include,
+			"exclude":  cfg.Exclude,
+		}
+	}
 
 	// Initialize sections for stable ordering
 	for _, k := range cfg.SectionKeys {
 		jsonOut.Sections[k] = []resultItem{}
 	}
 
-	// Normalize query tokens for scoring
-	kept, removed := normalizeTokens(originalQuery, cfg.Stopwords)
+	// Resolve each root's index once per search; the stages below share it.
+	// A root whose index was not ready before ctx ended keeps its previous
+	// index, or stays nil; its build carries on for the next search.
+	indexes := make(map[string]*searchIndex, len(cfg.Roots))
+	resolved := indexSet.resolve(ctx, cfg.Roots)
+	for i, root := range cfg.Roots {
+		indexes[root] = resolved[i]
+	}
+
+	// Normalize query tokens for scoring. An OR query runs one branch per
+	// combination of alternatives; scoring sees the union of their tokens.
+	branchKept := make([][]string, len(branches))
+	var kept, removed []string
+	for i, branch := range branches {
+		k, r := normalizeTokens(branch, cfg.Stopwords)
+		branchKept[i] = k
+		kept = append(kept, k...)
+		removed = append(removed, r...)
+	}
+	if len(branches) > 1 {
+		kept, removed = dedupeStrings(kept), dedupeStrings(removed)
+	}
 	jsonOut.Tokens["kept"] = kept
 	jsonOut.Tokens["removed"] = removed
+
+	// Fuzzy stage: a kept token found nowhere in the searched roots is
+	// replaced by its nearest corpus word before any stage matches. Tokens
+	// keeps the typed form under "kept" and the searched form under
+	// "expanded". A token with synonyms is a known word, searched through
+	// them, and never corrected.
+	var corrections []tokenCorrection
+	if cfg.FuzzyTokens {
+		var uncorrected []string
+		for _, token := range kept {
+			if len(cfg.Synonyms[token]) == 0 {
+				uncorrected = append(uncorrected, token)
+			}
+		}
+		corrections = correctTokens(uncorrected, resolved)
+	}
+	if len(corrections) > 0 {
+		kept = applyCorrections(kept, corrections)
+		for i := range branchKept {
+			branchKept[i] = applyCorrections(branchKept[i], corrections)
+		}
+		jsonOut.Tokens["expanded"] = kept
+		jsonOut.QueryPlan = append(jsonOut.QueryPlan, stageHit{Stage: "fuzzy", Query: strings.Join(kept, " ")})
+		fixed := make(map[string]string, len(corrections))
+		for _, c := range corrections {
+			fixed[c.From] = c.Display
+		}
+		jsonOut.Diagnostics["corrections"] = fixed
+	}
 	queryTerms := kept
 	if len(queryTerms) == 0 {
-		queryTerms = strings.Fields(strings.ToLower(originalQuery))
+		queryTerms = strings.Fields(strings.ToLower(strings.Join(branches, " ")))
 	}
 
 	// -------- Topic matching (Rec #4) --------
@@ -229,6 +401,8 @@ func ExecuteMediatedSearch(homeDir string, cfg MediatorConfig, originalQuery str
 		}
 	}
 
+	truncated := false
+
 	// -------- helpers --------
 
 	addFileHit := func(rel string, absPath string, lineNum int, line string, queryTermsForMatch []string) {
@@ -262,7 +436,7 @@ func ExecuteMediatedSearch(homeDir string, cfg MediatorConfig, originalQuery str
 		snippetLower := strings.ToLower(snippet)
 		termHits := 0
 		for _, term := range queryTermsForMatch {
-			if strings.Contains(snippetLower, term) {
+			if containsTermOrSynonym(snippetLower, term, cfg.Synonyms) {
 				sf.TermsFound[term] = true
 				termHits++
 			}
@@ -301,8 +475,55 @@ func ExecuteMediatedSearch(homeDir string, cfg MediatorConfig, originalQuery str
 		}
 	}
 
+	// scanRoot runs one stage over one root's index and records, per file,
+	// the hits the sequential scan used to apply directly.
+	scanRoot := func(idx *searchIndex, lq string, matchFunc func(string, string) bool, needles []string, minNeedles int) rootScan {
+		if idx == nil {
+			return rootScan{}
+		}
+		var scan rootScan
+		candidate := idx.candidateFiles(needles, minNeedles)
+		for i := range idx.Files {
+			if ctx.Err() != nil {
+				return scan
+			}
+			file := &idx.Files[i]
+			path := file.Path
+			rel, _ := filepath.Rel(homeDir, path)
+			if filter != nil && !filter.allowsFile(rel, cfg.Classifier(rel, path)) {
+				continue
+			}
+			fs := fileScan{rel: rel, path: path}
+
+			if cfg.EnableFilenameMatches && matchFunc(file.Name, lq) {
+				fs.events = append(fs.events, scanEvent{line: 0, text: "[filename match]"})
+			}
+
+			// A pruned file can have no line hit.
+			if !candidate[i] && len(fs.events) == 0 {
+				continue
+			}
+
+			for lineIdx, line := range file.Lines {
+				if matchFunc(line, lq) {
+					fs.events = append(fs.events, scanEvent{line: lineIdx + 1, text: line})
+				}
+			}
+			if len(fs.events) > 0 {
+				scan.files = append(scan.files, fs)
+			}
+		}
+		scan.complete = true
+		return scan
+	}
+
 	runStage := func(stageName, stageQuery string, roots []string, usePartialMatch bool) int {
 		hits := 0
+		if ctx.Err() != nil {
+			truncated = true
+			jsonOut.QueryPlan = append(jsonOut.QueryPlan, stageHit{Stage: stageName, Query: stageQuery, Hits: 0})
+			return 0
+		}
 		if stageQuery == "" {
 			jsonOut.QueryPlan = append(jsonOut.QueryPlan, stageHit{Stage: stageName, Query: stageQuery, Hits: 0})
 			return 0
@@ -313,78 +534,33 @@ func ExecuteMediatedSearch(homeDir string, cfg MediatorConfig, originalQuery str
 			minWords = calculateMinWords(len(strings.Fields(lq)))
 		}
 
-		for _, root := range roots {
-			_ = filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
-				if err != nil {
-					return nil
-				}
-				if d.IsDir() {
-					if d.Name() == "node_modules" || d.Name() == ".git" {
-						return filepath.SkipDir
-					}
-					return nil
-				}
-				if !hasAllowedExt(d.Name(), cfg.FileExtensions) {
-					return nil
-				}
-
-				var matchFunc func(string, string) bool
-				if usePartialMatch {
-					matchFunc = func(text, query string) bool {
-						return partialMatch(text, query, minWords)
-					}
-				} else {
-					matchFunc = fuzzyMatch
-				}
-
-				rel, _ := filepath.Rel(homeDir, path)
+		var matchFunc func(string, string) bool
+		if usePartialMatch {
+			matchFunc = func(text, query string) bool {
+				return partialMatch(text, query, minWords)
+			}
+		} else {
+			matchFunc = fuzzyMatch
+		}
+		needles, minNeedles := stageNeedles(lq, usePartialMatch, minWords)
 
-				if cfg.EnableFilenameMatches && matchFunc(d.Name(), lq) {
-					addFileHit(rel, path, 0, "[filename match]", queryTerms)
+		// Roots scan concurrently, each into its own slot, and are merged in
+		// root order below, so hit order (hence ranking) does not depend on
+		// scheduling. Workers only read the accumulators.
+		scans := make([]rootScan, len(roots))
+		forEachRoot(ctx, len(roots), func(r int) {
+			scans[r] = scanRoot(indexes[roots[r]], lq, matchFunc, needles, minNeedles)
+		})
+		for _, scan := range scans {
+			if !scan.complete {
+				truncated = true
+			}
+			for _, fs := range scan.files {
+				for _, ev := range fs.events {
+					addFileHit(fs.rel, fs.path, ev.line, ev.text, queryTerms)
 					hits++
 				}
-
-				f, err := os.Open(path)
-				if err != nil {
-					return nil
-				}
-				defer f.Close()
-
-				sc := bufio.NewScanner(f)
-				ln := 1
-				sawWarning := false
-				for sc.Scan() {
-					line := sc.Text()
-					if matchFunc(line, lq) {
-						addFileHit(rel, path, ln, line, queryTerms)
-						hits++
-					}
-					// Detect deprecation: [!WARNING] + "deprecated" nearby
-					if strings.Contains(line, "[!WARNING]") {
-						sawWarning = true
-					}
-					if sawWarning && strings.Contains(strings.ToLower(line), "deprecated") {
-						if sf, ok := fileScores[path]; ok {
-							sf.Deprecated = true
-						}
-					}
-					// Extract replacement link from deprecation block
-					if sawWarning {
-						if m := mdLinkRe.FindStringSubmatch(line); m != nil {
-							if sf, ok := fileScores[path]; ok && sf.ReplacementLink == "" {
-								sf.ReplacementText = m[1]
-								sf.ReplacementLink = m[2]
-							}
-						}
-						// Stop scanning the warning block after a blank line
-						if strings.TrimSpace(line) == "" {
-							sawWarning = false
-						}
-					}
-					ln++
-				}
-				return nil
-			})
+			}
 		}
 		jsonOut.QueryPlan = append(jsonOut.QueryPlan, stageHit{Stage: stageName, Query: lq, Hits: hits})
 		return hits
@@ -395,127 +571,130 @@ func ExecuteMediatedSearch(homeDir string, cfg MediatorConfig, originalQuery str
 	totalHits := 0
 
 	// Stage 1: exact
-	totalHits += runStage("exact", strings.ToLower(originalQuery), cfg.Roots, false)
+	for _, branch := range branches {
+		totalHits += runStage("exact", strings.ToLower(branch), cfg.Roots, false)
+	}
+
+	// Stages 2 and 3 also run each branch's synonym variants: the branch
+	// with one token swapped for a synonym ("grid" → "table").
+	var relaxedQueries [][]string
+	for _, k := range branchKept {
+		if len(k) > 0 {
+			relaxedQueries = append(relaxedQueries, k)
+			relaxedQueries = append(relaxedQueries, synonymVariants(k, cfg.Synonyms, maxQueryBranches)...)
+		}
+	}
 
 	// Stage 2: relaxed (strip sigils/stopwords)
-	if len(kept) > 0 {
-		relaxed := strings.Join(kept, " ")
+	for _, k := range relaxedQueries {
+		relaxed := strings.Join(k, " ")
 		totalHits += runStage("relaxed", relaxed, cfg.Roots, false)
 	}
 
 	// Stage 3: partial matching
-	if len(kept) > 0 {
-		relaxed := strings.Join(kept, " ")
+	for _, k := range relaxedQueries {
+		relaxed := strings.Join(k, " ")
 		roots := cfg.Roots
-		if looksLikeConcept(kept) && len(cfg.PreferSections) > 0 {
+		if looksLikeConcept(k) && len(cfg.PreferSections) > 0 {
 			roots = reorderRootsByPreference(cfg.Roots, cfg.PreferSections)
 		}
 		totalHits += runStage("partial", relaxed, roots, true)
-		jsonOut.Tokens["expanded"] = kept
-	}
-
-	// -------- Score files --------
-	sectionWeights := map[string]float64{
-		"components": 1.5,
-		"howtos":     1.5,
-		"examples":   1.2,
-		"source":     1.0,
-		"blog":       0.8,
-		"unknown":    0.5,
-	}
-
-	// Per-term document frequency over all candidates, computable here because
-	// scoring runs after hit collection. The filename bonus fires only for
-	// terms outside the in-corpus-generic band (the #14 rule): a slug hit on
-	// "component"/"input"-class tokens is coincidence, not relevance, and a
-	// flat +2.0 for them let eleven generic-slug docs outrank the doc whose
-	// body restates the query (#27). Small candidate sets skip the gate, as
-	// the salience band does.
-	bonusEligible := make(map[string]bool, len(queryTerms))
-	genericThreshold := -1
-	if len(fileScores) >= 3 {
-		genericThreshold = (2*len(fileScores) + 2) / 3
 	}
-	for _, term := range queryTerms {
-		if len(termStem(term)) < 4 {
-			continue
-		}
-		if genericThreshold < 0 {
-			bonusEligible[term] = true
-			continue
-		}
-		df := 0
-		for _, sf := range fileScores {
-			if sf.TermsFound[term] {
-				df++
-			}
+	if len(relaxedQueries) > 0 {
+		expanded := append([]string{}, kept...)
+		for _, k := range relaxedQueries {
+			expanded = append(expanded, k...)
 		}
-		bonusEligible[term] = df < genericThreshold
+		jsonOut.Tokens["expanded"] = dedupeStrings(expanded)
 	}
 
-	for _, sf := range fileScores {
-		// (a) Term coverage: distinct query terms found / total query terms
-		if len(queryTerms) > 0 {
-			sf.Score += float64(len(sf.TermsFound)) / float64(len(queryTerms))
-		}
-
-		// (b) Section weight
-		weight, ok := sectionWeights[sf.Section]
-		if !ok {
-			weight = 1.0
-		}
-		sf.Score *= weight
-
-		// (c) Filename match bonus: token-boundary, stem-aware, and only for
-		// terms outside the generic band (#11, #27).
-		for _, term := range queryTerms {
-			if bonusEligible[term] && filenameMatchesTerm(sf.RelPath, term) {
-				sf.Score += 2.0
-				sf.TitleMatch = true
-				break
-			}
-		}
+	truncatedReason := ""
+	if truncated {
+		truncatedReason = searchTruncation(ctx)
+		jsonOut.Diagnostics["truncated_reason"] = truncatedReason
+	}
+	jsonOut.Diagnostics["truncated"] = truncated
 
-		// (d) Topic bonus
-		for bonusPath := range topicBonusFiles {
-			if strings.Contains(sf.RelPath, bonusPath) {
-				sf.Score += 5.0
-				break
-			}
+	// -------- Score files --------
+	// Phrases, OR groups and exclusions are file-level constraints, applied
+	// to the collected files before ranking.
+	corpus := newCorpusStats(cfg.Roots, indexes)
+	candidates := make([]*scoredFile, 0, len(fileScores))
+	for _, sf := range fileScores {
+		var file *indexedFile
+		if doc := corpus.docs[sf.AbsPath]; doc != nil {
+			file = doc.file
 		}
-
-		// (e) Match density bonus (more snippets = more relevant)
-		sf.Score += float64(len(sf.Snippets)) * 0.1
-
-		// (f) Deprecation penalty: demote files with [!WARNING] + "deprecated"
-		if sf.Deprecated {
-			sf.Score *= 0.3
+		if !parsed.admitsFile(sf.RelPath, file) {
+			continue
 		}
+		markDeprecated(sf, corpus.indexes)
+		candidates = append(candidates, sf)
+	}
+	if cfg.Granularity == SearchGranularitySection {
+		candidates = splitIntoSections(candidates, corpus, queryTerms, cfg.Synonyms)
+		jsonOut.Diagnostics["granularity"] = SearchGranularitySection
 	}
+	explainQuery := map[string]any{}
+	scoreFiles(cfg.Scorer, candidates, ScoringContext{
+		QueryTerms:      queryTerms,
+		TopicBonusPaths: sortedKeys(topicBonusFiles),
+		Explain:         cfg.Explain,
+		corpus:          corpus,
+		explainQuery:    explainQuery,
+	})
 
 	// Sort files by score descending
-	ranked := make([]*scoredFile, 0, len(fileScores))
-	for _, sf := range fileScores {
-		ranked = append(ranked, sf)
-	}
+	ranked := candidates
 	sort.SliceStable(ranked, func(i, j int) bool {
 		// Tie-break by path: the pre-sort order comes from map iteration, so
 		// without this, equal-score files shuffle run to run and the top-N
 		// cutoff (hence df, salience, and analytics records) is nondeterministic.
 		if ranked[i].Score == ranked[j].Score {
+			if ranked[i].RelPath == ranked[j].RelPath {
+				return ranked[i].StartLine < ranked[j].StartLine
+			}
 			return ranked[i].RelPath < ranked[j].RelPath
 		}
 		return ranked[i].Score > ranked[j].Score
 	})
 
-	// Take top N files
-	if len(ranked) > cfg.MaxFileResults {
-		ranked = ranked[:cfg.MaxFileResults]
+	// Take the requested page of files. The path tie-break makes the order
+	// total, so consecutive offsets partition the ranking without overlap.
+	// Confidence and salience always describe the first page: they grade the
+	// top of the distribution, and must not drift as an agent pages.
+	totalFiles := len(ranked)
+	top := ranked
+	if len(top) > cfg.MaxFileResults {
+		top = top[:cfg.MaxFileResults]
+	}
+	pageStart := min(cfg.Offset, totalFiles)
+	pageEnd := min(pageStart+cfg.MaxFileResults, totalFiles)
+	ranked = ranked[pageStart:pageEnd]
+	if pageEnd < totalFiles {
+		jsonOut.NextCursor = encodeSearchCursor(cfg, originalQuery, pageEnd)
+	}
+	jsonOut.Diagnostics["total_files"] = totalFiles
+	if cfg.Explain {
+		explained := make([]ScoreExplanation, 0, len(ranked))
+		for _, sf := range ranked {
+			if sf.Explain == nil {
+				continue
+			}
+			ex := *sf.Explain
+			ex.Path, ex.Heading, ex.Score = sf.RelPath, sf.Heading, sf.Score
+			explained = append(explained, ex)
+		}
+		explainQuery["files"] = explained
+		jsonOut.Diagnostics["explain"] = explainQuery
+	}
+	if cfg.Offset > 0 {
+		jsonOut.Diagnostics["offset"] = cfg.Offset
 	}
 
 	// Salience is computed before sections are built so snippet selection can
 	// prefer lines covering the query's distinctive terms (#28).
-	salience := computeSalience(ranked, queryTerms)
+	salience := computeSalience(top, queryTerms)
 
 	// Build sections and facets from ranked files
 	uniqueFiles := make(map[string]map[string]struct{})
@@ -523,7 +702,8 @@ func ExecuteMediatedSearch(homeDir string, cfg MediatorConfig, originalQuery str
 		uniqueFiles[k] = make(map[string]struct{})
 	}
 
-	for _, sf := range ranked {
+	rankedItems := make([][]resultItem, len(ranked)) // parallel to ranked, for the human block
+	for r, sf := range ranked {
 		section := sf.Section
 		if _, ok := jsonOut.Sections[section]; !ok {
 			jsonOut.Sections[section] = []resultItem{}
@@ -533,8 +713,9 @@ func ExecuteMediatedSearch(homeDir string, cfg MediatorConfig, originalQuery str
 
 		// Pick best snippets: prefer title/heading lines, then first N
 		bestSnippets := pickBestSnippets(sf.Snippets, cfg.MaxSnippetsPerFile, queryTerms)
+		var items []resultItem
 		for _, snip := range bestSnippets {
-			jsonOut.Sections[section] = append(jsonOut.Sections[section], resultItem{
+			item := resultItem{
 				Type:       section,
 				Path:       sf.RelPath,
 				AbsPath:    sf.AbsPath,
@@ -542,8 +723,22 @@ func ExecuteMediatedSearch(homeDir string, cfg MediatorConfig, originalQuery str
 				Snippet:    snip.Text,
 				Score:      sf.Score,
 				TitleMatch: sf.TitleMatch,
-			})
+				Spans:      matchSpans(snip.Text, queryTerms, cfg.Synonyms),
+			}
+			if sf.EndLine > 0 {
+				item.Heading = sf.Heading
+				item.URL = sectionURL(sf)
+				item.SectionStartLine, item.SectionEndLine = sf.StartLine, sf.EndLine
+			}
+			items = append(items, item)
 		}
+		if cfg.ContextLines > 0 {
+			if doc := corpus.docs[sf.AbsPath]; doc != nil {
+				items = withContext(items, doc.file, cfg.ContextLines, cfg.MaxSnippetLength)
+			}
+		}
+		rankedItems[r] = items
+		jsonOut.Sections[section] = append(jsonOut.Sections[section], items...)
 	}
 
 	// Build facets
@@ -557,7 +752,7 @@ func ExecuteMediatedSearch(homeDir string, cfg MediatorConfig, originalQuery str
 	// Confidence from the top of the score distribution: "high" means the best
 	// hit covers most query terms and stands out from the tail, not that many
 	// files contained common tokens (#10).
-	jsonOut.Confidence = confidenceFromRanked(ranked, queryTerms)
+	jsonOut.Confidence = confidenceFromRanked(top, queryTerms)
 
 	// Salience summary for gap classification downstream (#12).
 	jsonOut.Salience = &salience
@@ -597,7 +792,7 @@ func ExecuteMediatedSearch(homeDir string, cfg MediatorConfig, originalQuery str
 	// same name can arrive by two paths — and NOT mirrored into RuleReminders:
 	// that mirror printed the line twice on the zero-hit path (#21, #23).
 	if len(ranked) == 0 || jsonOut.Confidence == "low" {
-		suggestions := dedupeStrings(suggestAlternatives(originalQuery, homeDir, 6))
+		suggestions := dedupeStrings(suggestAlternatives(branches[0], homeDir, 6))
 		if len(suggestions) > 3 {
 			suggestions = suggestions[:3]
 		}
@@ -606,13 +801,24 @@ func ExecuteMediatedSearch(homeDir string, cfg MediatorConfig, originalQuery str
 		}
 	}
 
-	// Related queries - removed for now
-	jsonOut.RelatedQueries = []string{}
+	// Related queries: past reformulations of this query, then topic
+	// headings beside its distinctive terms.
+	headings := cooccurringHeadings(queryTerms, salience.Terms, top)
+	jsonOut.RelatedQueries = relatedQueries(originalQuery, headings)
+	relatedAt := -1
 
 	// -------- Human block --------
 	var out strings.Builder
+	if len(ranked) == 0 && totalFiles > 0 {
+		fmt.Fprintf(&out, "No more results: offset %d is past the last of %d matching files.\n", cfg.Offset, totalFiles)
+		writeGuidanceBlock(&out, jsonOut)
+		return mediatedResult{human: out.String(), summary: jsonOut, headings: headings, relatedAt: relatedAt}, nil
+	}
 	if len(ranked) == 0 {
 		out.WriteString("No matches found.\n")
+		writeInterpretedLine(&out, parsed)
+		writeCorrectionsLine(&out, corrections)
+		writeTruncatedLine(&out, truncatedReason)
 		writeSalienceLines(&out, jsonOut)
 
 		hasGuidance := false
@@ -644,19 +850,29 @@ func ExecuteMediatedSearch(homeDir string, cfg MediatorConfig, originalQuery str
 		if len(jsonOut.Suggestions) > 0 {
 			out.WriteString("\nDid you mean: " + strings.Join(jsonOut.Suggestions, ", ") + "?\n")
 		}
+		relatedAt = out.Len()
+		writeRelatedLine(&out, jsonOut)
 
 		writeGuidanceBlock(&out, jsonOut)
-		return out.String(), jsonOut, nil
+		return mediatedResult{human: out.String(), summary: jsonOut, headings: headings, relatedAt: relatedAt}, nil
 	}
 
 	fmt.Fprintf(&out, "Query: %q  (files=%d, total_hits=%d, confidence=%s)\n",
 		originalQuery, len(ranked), totalHits, jsonOut.Confidence)
+	writeInterpretedLine(&out, parsed)
+	writeCorrectionsLine(&out, corrections)
+	writeTruncatedLine(&out, truncatedReason)
+	if pageStart > 0 || jsonOut.NextCursor != "" {
+		fmt.Fprintf(&out, "Page: files %d-%d of %d\n", pageStart+1, pageEnd, totalFiles)
+	}
 	writeSalienceLines(&out, jsonOut)
 
 	// Show topic matches
 	if len(jsonOut.TopicMatches) > 0 {
 		fmt.Fprintf(&out, "Topics: %s\n", strings.Join(jsonOut.TopicMatches, ", "))
 	}
+	relatedAt = out.Len()
+	writeRelatedLine(&out, jsonOut)
 
 	fmt.Fprintf(&out, "Facets: ")
 	keys := keysSortedV2(jsonOut.Facets)
@@ -673,9 +889,24 @@ func ExecuteMediatedSearch(homeDir string, cfg MediatorConfig, originalQuery str
 	}
 	out.WriteString("\n\n")
 
+	var mark func(string) string
+	if cfg.Highlight {
+		mark = func(line string) string {
+			return highlightSpans(line, matchSpans(line, queryTerms, cfg.Synonyms))
+		}
+	}
+
 	// Grouped-by-file output with scores
-	for _, sf := range ranked {
-		fmt.Fprintf(&out, "## %s  (score=%.2f, section=%s)\n", sf.RelPath, sf.Score, sf.Section)
+	for r, sf := range ranked {
+		if sf.EndLine > 0 {
+			fmt.Fprintf(&out, "## %s § %s  (L%d-%d, score=%.2f, section=%s)\n", sf.RelPath, sf.Heading, sf.StartLine, sf.EndLine, sf.Score, sf.Section)
+			if url := sectionURL(sf); url != "" {
+				fmt.Fprintf(&out, "  %s\n", url)
+			}
+		} else {
+			fmt.Fprintf(&out, "## %s  (score=%.2f, section=%s)\n", sf.RelPath, sf.Score, sf.Section)
+		}
+		writeExplainLine(&out, sf.Explain)
 		if sf.Deprecated {
 			if sf.ReplacementLink != "" {
 				fmt.Fprintf(&out, "  **DEPRECATED**: Use [%s](%s%s) instead.\n", sf.ReplacementText, constructURLBase(), sf.ReplacementLink)
@@ -683,13 +914,8 @@ func ExecuteMediatedSearch(homeDir string, cfg MediatorConfig, originalQuery str
 				out.WriteString("  **DEPRECATED**\n")
 			}
 		}
-		bestSnippets := pickBestSnippets(sf.Snippets, cfg.MaxSnippetsPerFile, queryTerms)
-		for _, snip := range bestSnippets {
-			if snip.Line == 0 {
-				fmt.Fprintf(&out, "  %s\n", snip.Text)
-			} else {
-				fmt.Fprintf(&out, "  L%d: %s\n", snip.Line, snip.Text)
-			}
+		for _, item := range rankedItems[r] {
+			writeResultItem(&out, item, mark)
 		}
 		out.WriteString("\n")
 	}
@@ -698,9 +924,33 @@ func ExecuteMediatedSearch(homeDir string, cfg MediatorConfig, originalQuery str
 		out.WriteString("Did you mean: " + strings.Join(jsonOut.Suggestions, ", ") + "?\n\n")
 	}
 
+	if jsonOut.NextCursor != "" {
+		fmt.Fprintf(&out, "More results: call again with cursor=%q (or offset=%d).\n\n", jsonOut.NextCursor, pageEnd)
+	}
+
 	writeGuidanceBlock(&out, jsonOut)
 
-	return out.String(), jsonOut, nil
+	return mediatedResult{human: out.String(), summary: jsonOut, headings: headings, relatedAt: relatedAt}, nil
+}
+
+// writeInterpretedLine shows how a query using the search grammar was read,
+// so a surprising result set can be traced to a misread operator.
+func writeInterpretedLine(out *strings.Builder, parsed ParsedQuery) {
+	if parsed.operators {
+		fmt.Fprintf(out, "Interpreted as: %s\n", parsed.Interpreted)
+	}
+}
+
+// writeTruncatedLine flags results ranked from a scan that stopped early
+// (reason is Diagnostics["truncated_reason"]), so an agent reads a miss as
+// "not searched" rather than "not there".
+func writeTruncatedLine(out *strings.Builder, reason string) {
+	switch reason {
+	case "deadline":
+		out.WriteString("Partial results (truncated): the search hit its time budget before scanning every file.\n")
+	case "canceled":
+		out.WriteString("Partial results (truncated): the search was canceled before scanning every file.\n")
+	}
 }
 
 // writeSalienceLines renders the in-band gap-vs-bad-query evidence (#18):
@@ -934,6 +1184,20 @@ func partialMatch(text, query string, minWords int) bool {
 	return found >= minWords
 }
 
+// stageNeedles returns the substrings a line must contain for matchFunc to
+// accept it, and how many of them are required — the same decomposition
+// fuzzyMatch and partialMatch apply, so index pruning never drops a match.
+func stageNeedles(lq string, usePartialMatch bool, minWords int) ([]string, int) {
+	words := strings.Fields(lq)
+	if len(words) == 1 || (usePartialMatch && len(words) == 0) {
+		return []string{lq}, 1
+	}
+	if usePartialMatch {
+		return words, minWords
+	}
+	return words, len(words)
+}
+
 // calculateMinWords: smart threshold calculation for partial matching.
 func calculateMinWords(totalWords int) int {
 	switch {
@@ -958,10 +1222,14 @@ func hasAllowedExt(name string, allowed []string) bool {
 	return false
 }
 
+// stageHit is one QueryPlan entry. The leading "parse" entry carries the
+// parsed query instead of hits; a "fuzzy" entry, present when tokens were
+// corrected, carries the corrected tokens.
 type stageHit struct {
-	Stage string `json:"stage"`
-	Query string `json:"query"`
-	Hits  int    `json:"hits"`
+	Stage  string       `json:"stage"`
+	Query  string       `json:"query"`
+	Hits   int          `json:"hits"`
+	Parsed *ParsedQuery `json:"parsed,omitempty"`
 }
 
 type resultItem struct {
@@ -972,6 +1240,23 @@ type resultItem struct {
 	Snippet    string  `json:"snippet"`
 	Score      float64 `json:"score,omitempty"`
 	TitleMatch bool    `json:"title_match,omitempty"`
+
+	// Where the query terms match in Snippet, on the same basis as
+	// Salience.TermCoverage; see matchSpans.
+	Spans []MatchSpan `json:"spans,omitempty"`
+
+	// Set when context lines were requested: the window's line range and
+	// its lines, which include Line.
+	StartLine int      `json:"start_line,omitempty"`
+	EndLine   int      `json:"end_line,omitempty"`
+	Context   []string `json:"context,omitempty"`
+
+	// Set on section results: the heading-bounded section Line falls in,
+	// its heading, and the docs URL with the section's anchor.
+	Heading          string `json:"heading,omitempty"`
+	URL              string `json:"url,omitempty"`
+	SectionStartLine int    `json:"section_start_line,omitempty"`
+	SectionEndLine   int    `json:"section_end_line,omitempty"`
 }
 
 // normalizeTokens: lowercase, strip simple punctuation/sigils, drop stopwords.
@@ -1290,9 +1575,21 @@ func DefaultStopwords() map[string]struct{} {
 	return stopwords
 }
 
-// DefaultSynonyms provides minimal, generic expansions; override if desired.
+// DefaultSynonyms maps everyday UI words to the XMLUI component that
+// implements them. Corpus and user dictionaries layer over it (see
+// SearchDictionary).
 func DefaultSynonyms() map[string][]string {
-	return map[string][]string{}
+	return map[string][]string{
+		"modal":    {"modaldialog"},
+		"dialog":   {"modaldialog"},
+		"popup":    {"modaldialog"},
+		"grid":     {"table"},
+		"dropdown": {"select", "dropdownmenu"},
+		"combobox": {"select", "autocomplete"},
+		"calendar": {"datepicker"},
+		"toggle":   {"switch"},
+		"loader":   {"spinner"},
+	}
 }
 
 // detectFeatureCombination identifies when query asks for combining features that aren't documented together
@@ -0,0 +1,287 @@
+package server
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// ParsedQuery is how the mediator read a query written in the small search
+// grammar: bare words, "quoted phrases", -exclusions, OR between words or
+// phrases, and component:/section:/ext: field prefixes. It is reported as
+// the leading "parse" entry of MediatorJSON.QueryPlan.
+//
+// Bare words rank as before (staged exact/relaxed/partial matching). The
+// other constructs are hard constraints on a result file: each phrase must
+// occur in one line, each OR group must have an alternative present, and no
+// excluded term may occur in the file's path or text.
+type ParsedQuery struct {
+	Terms   []string            `json:"terms"`
+	Phrases []string            `json:"phrases,omitempty"`
+	AnyOf   [][]string          `json:"any_of,omitempty"`
+	Exclude []string            `json:"exclude,omitempty"`
+	Fields  map[string][]string `json:"fields,omitempty"`
+
+	// Interpreted is the canonical rendering of the parsed form.
+	Interpreted string `json:"interpreted"`
+
+	operators bool        // any grammar construct was used
+	items     []queryItem // positive items in query order, for branch texts
+}
+
+// queryGrammarHint is appended to the search tools' query descriptions.
+const queryGrammarHint = ` Supports "quoted phrases", -exclusions, OR between alternatives, and component:/section:/ext: fields.`
+
+// queryFields are the recognized field: prefixes.
+var queryFields = []string{"component", "section", "ext"}
+
+// maxQueryBranches caps how many OR combinations are run as stage queries.
+const maxQueryBranches = 8
+
+// queryItem is one positive unit of the query: a word, a phrase, or an OR
+// group of either.
+type queryItem struct {
+	text   string // word or phrase; empty for a group
+	phrase bool
+	anyOf  []string // OR alternatives
+}
+
+// parseQuery reads q. A query using no grammar constructs parses to its
+// bare words and searches exactly as it always has. Quotes only delimit a
+// phrase at token boundaries and a dash only excludes at the start of a
+// word, so markup such as delay="1000" or --xmlui-color stays literal.
+func parseQuery(q string) ParsedQuery {
+	pq := ParsedQuery{Terms: []string{}}
+	var tokens []queryToken
+	for i := 0; i < len(q); {
+		if q[i] == ' ' || q[i] == '\t' || q[i] == '\n' {
+			i++
+			continue
+		}
+		if q[i] == '"' {
+			if end := strings.IndexByte(q[i+1:], '"'); end > 0 {
+				after := i + 1 + end + 1
+				if after == len(q) || q[after] == ' ' || q[after] == '\t' || q[after] == '\n' {
+					tokens = append(tokens, queryToken{text: q[i+1 : i+1+end], phrase: true})
+					i = after
+					continue
+				}
+			}
+		}
+		end := strings.IndexAny(q[i:], " \t\n")
+		if end < 0 {
+			end = len(q) - i
+		}
+		tokens = append(tokens, queryToken{text: q[i : i+end]})
+		i += end
+	}
+
+	var pending []queryToken // positive tokens awaiting OR grouping
+	for idx := 0; idx < len(tokens); idx++ {
+		tok := tokens[idx]
+		switch {
+		case !tok.phrase && tok.text == "OR" && len(pending) > 0 && idx+1 < len(tokens) && isPositiveToken(tokens[idx+1]):
+			pq.operators = true
+			pending[len(pending)-1].or = append(pending[len(pending)-1].or, tokens[idx+1])
+			idx++
+		case !tok.phrase && isExclusionToken(tok.text) && len(tokens) > 1:
+			pq.operators = true
+			pq.Exclude = append(pq.Exclude, strings.ToLower(tok.text[1:]))
+		case !tok.phrase && queryFieldOf(tok.text) != "":
+			pq.operators = true
+			field := queryFieldOf(tok.text)
+			value := tok.text[len(field)+1:]
+			if pq.Fields == nil {
+				pq.Fields = map[string][]string{}
+			}
+			pq.Fields[field] = append(pq.Fields[field], value)
+		default:
+			if tok.phrase {
+				pq.operators = true
+			}
+			pending = append(pending, tok)
+		}
+	}
+
+	for _, tok := range pending {
+		if len(tok.or) > 0 {
+			group := []string{strings.ToLower(tok.text)}
+			for _, alt := range tok.or {
+				group = append(group, strings.ToLower(alt.text))
+			}
+			pq.AnyOf = append(pq.AnyOf, group)
+			pq.items = append(pq.items, queryItem{anyOf: group})
+			continue
+		}
+		if tok.phrase {
+			pq.Phrases = append(pq.Phrases, strings.ToLower(tok.text))
+		} else {
+			pq.Terms = append(pq.Terms, strings.ToLower(tok.text))
+		}
+		pq.items = append(pq.items, queryItem{text: tok.text, phrase: tok.phrase})
+	}
+
+	// A query of constraints alone ("component:Table") searches for the
+	// named components so it still has something to rank.
+	if len(pq.items) == 0 {
+		for _, name := range pq.Fields["component"] {
+			pq.items = append(pq.items, queryItem{text: name})
+		}
+	}
+	pq.Interpreted = pq.render()
+	return pq
+}
+
+// queryToken is a raw token; or collects alternatives joined to it by OR.
+type queryToken struct {
+	text   string
+	phrase bool
+	or     []queryToken
+}
+
+func isPositiveToken(tok queryToken) bool {
+	return tok.phrase || (tok.text != "OR" && !isExclusionToken(tok.text) && queryFieldOf(tok.text) == "")
+}
+
+// isExclusionToken accepts "-word" but not "-", "--custom-prop" or "-1".
+func isExclusionToken(tok string) bool {
+	if len(tok) < 2 || tok[0] != '-' {
+		return false
+	}
+	c := tok[1]
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_' || c == '$'
+}
+
+// queryFieldOf returns the field name when tok is "field:value" for a
+// recognized field with a non-empty value.
+func queryFieldOf(tok string) string {
+	name, value, ok := strings.Cut(tok, ":")
+	if !ok || value == "" {
+		return ""
+	}
+	name = strings.ToLower(name)
+	for _, f := range queryFields {
+		if name == f {
+			return f
+		}
+	}
+	return ""
+}
+
+// render writes the canonical form: words, phrases and OR groups in query
+// order, then exclusions, then fields.
+func (pq ParsedQuery) render() string {
+	var parts []string
+	for _, item := range pq.items {
+		switch {
+		case len(item.anyOf) > 0:
+			alts := make([]string, len(item.anyOf))
+			for i, alt := range item.anyOf {
+				if strings.Contains(alt, " ") {
+					alt = `"` + alt + `"`
+				}
+				alts[i] = alt
+			}
+			parts = append(parts, "("+strings.Join(alts, " OR ")+")")
+		case item.phrase:
+			parts = append(parts, `"`+strings.ToLower(item.text)+`"`)
+		default:
+			parts = append(parts, strings.ToLower(item.text))
+		}
+	}
+	for _, ex := range pq.Exclude {
+		parts = append(parts, "-"+ex)
+	}
+	for _, field := range queryFields {
+		for _, value := range pq.Fields[field] {
+			parts = append(parts, field+":"+value)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// branchTexts returns the free-text queries the stages run: one per
+// combination of OR alternatives, capped at maxQueryBranches. A query
+// without grammar constructs yields itself unchanged, so the exact stage
+// still sees its punctuation.
+func (pq ParsedQuery) branchTexts(original string) []string {
+	if !pq.operators {
+		return []string{original}
+	}
+	branches := [][]string{{}}
+	for _, item := range pq.items {
+		alts := item.anyOf
+		if len(alts) == 0 {
+			alts = []string{item.text}
+		}
+		var next [][]string
+		for _, branch := range branches {
+			for _, alt := range alts {
+				if len(next) == maxQueryBranches {
+					break
+				}
+				extended := append(append([]string{}, branch...), alt)
+				next = append(next, extended)
+			}
+		}
+		branches = next
+	}
+	texts := make([]string, 0, len(branches))
+	for _, branch := range branches {
+		texts = append(texts, strings.Join(branch, " "))
+	}
+	return texts
+}
+
+// admitsFile applies the hard constraints to one collected file.
+func (pq ParsedQuery) admitsFile(rel string, file *indexedFile) bool {
+	if len(pq.Phrases) == 0 && len(pq.AnyOf) == 0 && len(pq.Exclude) == 0 {
+		return true
+	}
+	relLower := strings.ToLower(filepath.ToSlash(rel))
+	for _, ex := range pq.Exclude {
+		if strings.Contains(relLower, ex) {
+			return false
+		}
+	}
+	var lines []string
+	if file != nil {
+		lines = make([]string, len(file.Lines))
+		for i, line := range file.Lines {
+			lines[i] = strings.ToLower(line)
+		}
+	}
+	contains := func(needle string) bool {
+		if strings.Contains(strings.ToLower(filepath.Base(rel)), needle) {
+			return true
+		}
+		for _, line := range lines {
+			if strings.Contains(line, needle) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, ex := range pq.Exclude {
+		if contains(ex) {
+			return false
+		}
+	}
+	for _, phrase := range pq.Phrases {
+		if !contains(phrase) {
+			return false
+		}
+	}
+	for _, group := range pq.AnyOf {
+		found := false
+		for _, alt := range group {
+			if contains(alt) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseQueryGrammar(t *testing.T) {
+	pq := parseQuery(`"DataSource" -blog onClick OR onDoubleClick component:Table ext:tsx`)
+	if !reflect.DeepEqual(pq.Phrases, []string{"datasource"}) {
+		t.Errorf("phrases = %v", pq.Phrases)
+	}
+	if !reflect.DeepEqual(pq.Exclude, []string{"blog"}) {
+		t.Errorf("exclude = %v", pq.Exclude)
+	}
+	if !reflect.DeepEqual(pq.AnyOf, [][]string{{"onclick", "ondoubleclick"}}) {
+		t.Errorf("any_of = %v", pq.AnyOf)
+	}
+	if !reflect.DeepEqual(pq.Fields, map[string][]string{"component": {"Table"}, "ext": {"tsx"}}) {
+		t.Errorf("fields = %v", pq.Fields)
+	}
+	want := `"datasource" (onclick OR ondoubleclick) -blog component:Table ext:tsx`
+	if pq.Interpreted != want {
+		t.Errorf("interpreted = %q, want %q", pq.Interpreted, want)
+	}
+	if got := pq.branchTexts(""); !reflect.DeepEqual(got, []string{"DataSource onclick", "DataSource ondoubleclick"}) {
+		t.Errorf("branches = %q", got)
+	}
+}
+
+// Markup and CSS in a plain query must not be read as grammar.
+func TestParseQueryLeavesLiteralsAlone(t *testing.T) {
+	for _, q := range []string{`delay="1000"`, `--xmlui-color-primary`, `-webkit`, `pathname context variable`, `a - b`} {
+		pq := parseQuery(q)
+		if pq.operators {
+			t.Errorf("%q parsed as grammar: %+v", q, pq)
+		}
+		if got := pq.branchTexts(q); len(got) != 1 || got[0] != q {
+			t.Errorf("%q branches = %q, want the query unchanged", q, got)
+		}
+	}
+}
+
+func TestQueryGrammarConstrainsResults(t *testing.T) {
+	resetTopicIndexForTest(t)
+	root := t.TempDir()
+	howtoDir := filepath.Join(root, "howto")
+	if err := os.MkdirAll(howtoDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeHowtoFixture(t, howtoDir, "click.md", "# Handle a click\nUse onClick on the Button.\n")
+	writeHowtoFixture(t, howtoDir, "double-click.md", "# Handle a double click\nUse onDoubleClick on the Table row.\n")
+	writeHowtoFixture(t, howtoDir, "blog-click.md", "# Click stories\nA blog post about onClick handlers.\n")
+	writeHowtoFixture(t, howtoDir, "Table.md", "# Table\nRows handle a double click.\n")
+
+	cfg := howtoMediatorConfig(howtoDir)
+	paths := func(query string) []string {
+		t.Helper()
+		_, summary, err := ExecuteMediatedSearch(root, cfg, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if summary.QueryPlan[0].Stage != "parse" || summary.QueryPlan[0].Parsed == nil {
+			t.Fatalf("query plan does not lead with the parse: %+v", summary.QueryPlan)
+		}
+		return pagedPaths(summary)
+	}
+
+	got := paths("onClick OR onDoubleClick -blog")
+	if strings.Join(got, ",") != "howto/click.md,howto/double-click.md" && strings.Join(got, ",") != "howto/double-click.md,howto/click.md" {
+		t.Errorf("OR with exclusion returned %v", got)
+	}
+	if got := paths(`"double click"`); len(got) != 2 {
+		t.Errorf("phrase returned %v, want the two docs with the phrase", got)
+	}
+	if got := paths(`"double click" component:Table`); len(got) != 1 || got[0] != "howto/Table.md" {
+		t.Errorf("component field returned %v", got)
+	}
+}
@@ -0,0 +1,197 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+)
+
+// Search modes selectable through MediatorConfig.Mode and xmlui_search's
+// "mode" argument.
+const (
+	SearchModeWords = "words"
+	SearchModeRegex = "regex"
+)
+
+// Guards for regex mode. Go's RE2 engine already runs in linear time, so
+// no pattern can backtrack catastrophically; these bound what linear still
+// allows: huge compiled programs and patterns that match every line.
+const (
+	maxRegexPatternLength = 256
+	maxRegexProgramSize   = 2000
+	defaultRegexMatches   = 200
+)
+
+// compileSearchRegex compiles pattern for regex mode, rejecting patterns
+// that are too large or that match the empty string (and so every line).
+func compileSearchRegex(pattern string) (*regexp.Regexp, error) {
+	if len(pattern) > maxRegexPatternLength {
+		return nil, fmt.Errorf("regex pattern is %d characters; the limit is %d", len(pattern), maxRegexPatternLength)
+	}
+	parsed, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex pattern: %v", err)
+	}
+	prog, err := syntax.Compile(parsed.Simplify())
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex pattern: %v", err)
+	}
+	if len(prog.Inst) > maxRegexProgramSize {
+		return nil, fmt.Errorf("regex pattern is too complex (%d instructions; the limit is %d): drop large counted repetitions", len(prog.Inst), maxRegexProgramSize)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex pattern: %v", err)
+	}
+	if re.MatchString("") {
+		return nil, fmt.Errorf("regex pattern %q matches the empty string, so it would match every line: anchor it to required text", pattern)
+	}
+	return re, nil
+}
+
+// executeRegexSearch is ExecuteMediatedSearch in SearchModeRegex: every
+// line of every allowed file under the roots is matched against pattern,
+// in index (walk) order, until MaxRegexMatches lines have matched. Results
+// use the staged search's resultItem shape with a per-match line number;
+// files are listed in walk order, since a regex has no relevance to rank by.
+func executeRegexSearch(ctx context.Context, homeDir string, cfg MediatorConfig, pattern string, indexSet *searchIndexSet) (string, MediatorJSON, error) {
+	if cfg.MaxRegexMatches <= 0 {
+		cfg.MaxRegexMatches = defaultRegexMatches
+	}
+	re, err := compileSearchRegex(pattern)
+	if err != nil {
+		return "", MediatorJSON{}, err
+	}
+	filter, err := newSearchFilter(cfg.Sections, cfg.Include, cfg.Exclude)
+	if err != nil {
+		return "", MediatorJSON{}, err
+	}
+	cfg.Roots = filter.pruneRoots(homeDir, cfg.Roots)
+
+	jsonOut := MediatorJSON{
+		QueryPlan:      []stageHit{},
+		Tokens:         map[string][]string{"kept": {}, "removed": {}, "expanded": {}},
+		Sections:       make(map[string][]resultItem),
+		Facets:         make(map[string]FacetCounts),
+		RelatedQueries: []string{},
+		Diagnostics: map[string]any{
+			"original_query": pattern,
+			"mode":           SearchModeRegex,
+			"match_budget":   cfg.MaxRegexMatches,
+		},
+	}
+	for _, k := range cfg.SectionKeys {
+		jsonOut.Sections[k] = []resultItem{}
+	}
+
+	type regexFile struct {
+		rel, section string
+		file         *indexedFile
+		items        []resultItem
+		matches      int
+	}
+	var files []*regexFile
+	seen := map[string]bool{}
+	matches := 0
+	truncated := ""
+	indexes := indexSet.resolve(ctx, cfg.Roots)
+
+	// Lines are matched in root and walk order so the budget keeps the same
+	// prefix of matches on every run.
+scan:
+	for _, idx := range indexes {
+		if idx == nil {
+			truncated = searchTruncation(ctx)
+			break
+		}
+		for i := range idx.Files {
+			if ctx.Err() != nil {
+				truncated = searchTruncation(ctx)
+				break scan
+			}
+			file := &idx.Files[i]
+			if seen[file.Path] {
+				continue
+			}
+			seen[file.Path] = true
+			rel := toRepoRelative(homeDir, file.Path)
+			section := cfg.Classifier(rel, file.Path)
+			if !filter.allowsFile(rel, section) {
+				continue
+			}
+			var current *regexFile
+			for lineIdx, line := range file.Lines {
+				if !re.MatchString(line) {
+					continue
+				}
+				if matches == cfg.MaxRegexMatches {
+					truncated = "match_budget"
+					break scan
+				}
+				matches++
+				if current == nil {
+					current = &regexFile{rel: rel, section: section, file: file}
+					files = append(files, current)
+				}
+				snippet := line
+				if len(snippet) > cfg.MaxSnippetLength {
+					snippet = snippet[:cfg.MaxSnippetLength] + "..."
+				}
+				current.items = append(current.items, resultItem{
+					Type:    section,
+					Path:    rel,
+					AbsPath: file.Path,
+					Line:    lineIdx + 1,
+					Snippet: snippet,
+				})
+			}
+		}
+	}
+
+	for _, f := range files {
+		facet := jsonOut.Facets[f.section]
+		facet.Files++
+		facet.Matches += len(f.items)
+		jsonOut.Facets[f.section] = facet
+		f.matches = len(f.items)
+		f.items = withContext(f.items, f.file, cfg.ContextLines, cfg.MaxSnippetLength)
+		jsonOut.Sections[f.section] = append(jsonOut.Sections[f.section], f.items...)
+	}
+	jsonOut.QueryPlan = append(jsonOut.QueryPlan, stageHit{Stage: SearchModeRegex, Query: pattern, Hits: matches})
+	jsonOut.Diagnostics["truncated"] = truncated != ""
+	if truncated != "" {
+		jsonOut.Diagnostics["truncated_reason"] = truncated
+	}
+	if matches > 0 {
+		jsonOut.Confidence = "high"
+	} else {
+		jsonOut.Confidence = "low"
+		jsonOut.AgentGuidance = &AgentGuidance{
+			RuleReminders:     []string{},
+			SuggestedApproach: "No line matched. Regex mode is case-sensitive RE2 syntax: prefix (?i) to ignore case, and widen sections/include filters if they were set.",
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "Regex: /%s/  (files=%d, matches=%d)\n", pattern, len(files), matches)
+	if truncated == "match_budget" {
+		fmt.Fprintf(&out, "Stopped at the %d-match budget: narrow the pattern or add sections/include filters to see the rest.\n", cfg.MaxRegexMatches)
+	} else {
+		writeTruncatedLine(&out, truncated)
+	}
+	out.WriteString("\n")
+	if len(files) == 0 {
+		out.WriteString("No matches found.\n")
+	}
+	for _, f := range files {
+		fmt.Fprintf(&out, "## %s  (matches=%d, section=%s)\n", f.rel, f.matches, f.section)
+		for _, item := range f.items {
+			writeResultItem(&out, item, nil)
+		}
+		out.WriteString("\n")
+	}
+	writeGuidanceBlock(&out, jsonOut)
+	return out.String(), jsonOut, nil
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompileSearchRegexGuards(t *testing.T) {
+	if _, err := compileSearchRegex(`use[A-Z]\w+Event`); err != nil {
+		t.Fatalf("ordinary pattern rejected: %v", err)
+	}
+	for _, pattern := range []string{`.*`, `a?`, `(x|)`, strings.Repeat("a", maxRegexPatternLength+1), `(a{1,1000}){1,1000}`, `((((a{100}){100}){100}){100})`, `(`} {
+		if _, err := compileSearchRegex(pattern); err == nil {
+			t.Errorf("pattern %.40q accepted", pattern)
+		}
+	}
+}
+
+func TestRegexModeMatchesLinesWithinBudget(t *testing.T) {
+	resetTopicIndexForTest(t)
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"Button.tsx": "const onClick = useClickEvent();\nconst x = 1;\nuseHoverEvent(x);\n",
+		"Table.tsx":  "export const TableMd = createMetadata({\n  useRowEvent: true,\n});\n",
+		"notes.md":   "useNoteEvent is documented here\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := MediatorConfig{
+		Roots:          []string{src},
+		SectionKeys:    []string{"source"},
+		FileExtensions: []string{".tsx"},
+		Classifier:     func(string, string) string { return "source" },
+		Mode:           SearchModeRegex,
+	}
+	human, summary, err := ExecuteMediatedSearch(root, cfg, `use[A-Z]\w+Event`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := summary.Sections["source"]
+	var got []string
+	for _, item := range items {
+		got = append(got, fmt.Sprintf("%s:%d", filepath.Base(item.Path), item.Line))
+	}
+	if strings.Join(got, ",") != "Button.tsx:1,Button.tsx:3,Table.tsx:2" {
+		t.Fatalf("matches = %v", got)
+	}
+	if summary.Diagnostics["truncated"] != false || !strings.Contains(human, "L3: useHoverEvent(x);") {
+		t.Fatalf("unexpected output:\n%s", human)
+	}
+
+	cfg.MaxRegexMatches = 2
+	human, summary, err = ExecuteMediatedSearch(root, cfg, `use[A-Z]\w+Event`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summary.Sections["source"]) != 2 || summary.Diagnostics["truncated"] != true {
+		t.Fatalf("budget not enforced: %+v", summary.Sections)
+	}
+	if !strings.Contains(human, "2-match budget") {
+		t.Fatalf("human block does not report the budget:\n%s", human)
+	}
+}
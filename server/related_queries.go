@@ -0,0 +1,170 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxRelatedQueries caps MediatorJSON.RelatedQueries.
+const maxRelatedQueries = 5
+
+// reformulationWindow is how soon after a zero-result query a successful
+// one must follow to count as its reformulation. Longer gaps are more
+// likely a new question than a retry.
+const reformulationWindow = 5 * time.Minute
+
+// relatedQueries suggests follow-up queries: first the queries that earlier
+// sessions reached after this one found nothing, then headings (see
+// cooccurringHeadings). Reformulations are read from analytics on every
+// call, so a cached search passes its stored headings back in.
+func relatedQueries(query string, headings []string) []string {
+	var out []string
+	seen := map[string]bool{foldQuery(query): true}
+	add := func(q string) {
+		if key := foldQuery(q); !seen[key] && len(out) < maxRelatedQueries {
+			seen[key] = true
+			out = append(out, q)
+		}
+	}
+	if globalAnalytics != nil {
+		for _, q := range globalAnalytics.reformulations(query) {
+			add(q)
+		}
+	}
+	for _, heading := range headings {
+		add(heading)
+	}
+	return out
+}
+
+// foldQuery folds case and runs of whitespace, so queries differing only in
+// those count as one suggestion.
+func foldQuery(q string) string {
+	return strings.ToLower(strings.Join(strings.Fields(q), " "))
+}
+
+// cooccurringHeadings returns topic headings with a trigger term sharing a
+// stem with a distinctive query term, ordered by shared terms, then by
+// whether the heading's page was ranked (the corpus itself put those beside
+// the query's terms), then shorter and alphabetical. Headings made only of
+// query words restate the query and are skipped.
+func cooccurringHeadings(queryTerms, distinctive []string, ranked []*scoredFile) []string {
+	if len(distinctive) == 0 {
+		return nil
+	}
+	stems := make(map[string]bool, len(distinctive))
+	for _, term := range distinctive {
+		stems[termStem(term)] = true
+	}
+	inQuery := make(map[string]bool, len(queryTerms))
+	for _, term := range queryTerms {
+		inQuery[termStem(term)] = true
+	}
+	rankedPaths := make(map[string]bool, len(ranked))
+	for _, sf := range ranked {
+		rankedPaths[sf.RelPath] = true
+	}
+
+	type candidate struct {
+		heading string
+		shared  int
+		ranked  bool
+	}
+	var candidates []candidate
+	for _, topic := range topicIndex {
+		shared, novel := 0, false
+		for _, trigger := range topic.TriggerTerms {
+			stem := termStem(trigger)
+			if stems[stem] {
+				shared++
+			}
+			if !inQuery[stem] {
+				novel = true
+			}
+		}
+		if shared == 0 || !novel {
+			continue
+		}
+		c := candidate{heading: topic.Name, shared: shared}
+		for _, doc := range topic.CanonicalDocs {
+			c.ranked = c.ranked || rankedPaths[doc]
+		}
+		candidates = append(candidates, c)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		switch {
+		case a.shared != b.shared:
+			return a.shared > b.shared
+		case a.ranked != b.ranked:
+			return a.ranked
+		case len(a.heading) != len(b.heading):
+			return len(a.heading) < len(b.heading)
+		default:
+			return a.heading < b.heading
+		}
+	})
+	headings := make([]string, len(candidates))
+	for i, c := range candidates {
+		headings[i] = c.heading
+	}
+	return headings
+}
+
+// reformulations returns the queries that followed query in the log when
+// it found nothing: within reformulationWindow of a zero-result run of
+// queries, the next query that yielded results. Most frequent first, then
+// alphabetical. Only schema-v2 records say whether a query yielded results.
+func (a *Analytics) reformulations(query string) []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	target := foldQuery(query)
+	counts := make(map[string]int)
+	var pending map[string]bool // zero-result queries awaiting a success
+	var last time.Time
+	for _, sq := range a.data.SearchQueries {
+		if sq.SchemaVersion != 2 || sq.ExecutionSuccess == nil || sq.YieldedResults == nil || !*sq.ExecutionSuccess {
+			continue
+		}
+		if sq.Timestamp.Sub(last) > reformulationWindow {
+			pending = nil
+		}
+		last = sq.Timestamp
+		key := foldQuery(sq.Query)
+		if !*sq.YieldedResults {
+			if pending == nil {
+				pending = make(map[string]bool)
+			}
+			pending[key] = true
+			continue
+		}
+		if pending[target] && key != target {
+			counts[strings.TrimSpace(sq.Query)]++
+		}
+		pending = nil
+	}
+
+	out := make([]string, 0, len(counts))
+	for q := range counts {
+		out = append(out, q)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if counts[out[i]] == counts[out[j]] {
+			return out[i] < out[j]
+		}
+		return counts[out[i]] > counts[out[j]]
+	})
+	return out
+}
+
+// writeRelatedLine lists related queries when the search was not
+// confident, as places to go next.
+func writeRelatedLine(out *strings.Builder, summary MediatorJSON) {
+	if summary.Confidence == "high" || len(summary.RelatedQueries) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "Related queries: %s\n", strings.Join(summary.RelatedQueries, "; "))
+}
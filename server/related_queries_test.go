@@ -0,0 +1,76 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func recordSearchForTest(a *Analytics, at time.Time, query string, yielded bool) {
+	executed := true
+	a.data.SearchQueries = append(a.data.SearchQueries, SearchQuery{
+		SchemaVersion:    2,
+		Type:             "search_query",
+		Timestamp:        at,
+		Query:            query,
+		ExecutionSuccess: &executed,
+		YieldedResults:   &yielded,
+	})
+}
+
+func TestReformulationsFollowZeroResultChains(t *testing.T) {
+	a := useTestAnalytics(t)
+	start := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	recordSearchForTest(a, start, "paginate rows", false)
+	recordSearchForTest(a, start.Add(time.Minute), "page rows", false)
+	recordSearchForTest(a, start.Add(2*time.Minute), "Table pageSize", true)
+	// A second session: the retry came too late to count.
+	recordSearchForTest(a, start.Add(time.Hour), "Paginate  rows", false)
+	recordSearchForTest(a, start.Add(2*time.Hour), "List pagination", true)
+	// A third: a quick retry.
+	recordSearchForTest(a, start.Add(3*time.Hour), "paginate rows", false)
+	recordSearchForTest(a, start.Add(3*time.Hour+time.Minute), "Table pageSize", true)
+	// A query that found results is not reformulated.
+	recordSearchForTest(a, start.Add(4*time.Hour), "paginate rows", true)
+	recordSearchForTest(a, start.Add(4*time.Hour+time.Minute), "Pagination", true)
+
+	if got := a.reformulations("paginate rows"); !reflect.DeepEqual(got, []string{"Table pageSize"}) {
+		t.Fatalf("reformulations = %v", got)
+	}
+	if got := a.reformulations("page rows"); !reflect.DeepEqual(got, []string{"Table pageSize"}) {
+		t.Fatalf("reformulations of a mid-chain query = %v", got)
+	}
+}
+
+func TestRelatedQueriesShownWhenConfidenceIsNotHigh(t *testing.T) {
+	resetTopicIndexForTest(t)
+	a := useTestAnalytics(t)
+	recordSearchForTest(a, time.Now().Add(-2*time.Minute), "paginate rows", false)
+	recordSearchForTest(a, time.Now().Add(-time.Minute), "Table pageSize", true)
+	topicIndex = []TopicEntry{
+		{Name: "Rows per page", TriggerTerms: []string{"rows", "per", "page"}},
+		{Name: "Rows", TriggerTerms: []string{"rows"}},
+		{Name: "Styling", TriggerTerms: []string{"styling"}},
+	}
+
+	root := t.TempDir()
+	howtoDir := filepath.Join(root, "howto")
+	if err := os.MkdirAll(howtoDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeHowtoFixture(t, howtoDir, "layout.md", "# Layout\nStack children vertically.\n")
+
+	human, summary, err := ExecuteMediatedSearch(root, howtoMediatorConfig(howtoDir), "paginate rows")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Table pageSize", "Rows per page"}; !reflect.DeepEqual(summary.RelatedQueries, want) {
+		t.Fatalf("related = %v, want %v", summary.RelatedQueries, want)
+	}
+	if !strings.Contains(human, "Related queries: Table pageSize; Rows per page\n") {
+		t.Fatalf("human block lacks related queries:\n%s", human)
+	}
+}
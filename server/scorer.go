@@ -0,0 +1,516 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Scorer ranks the candidates a mediated search collected. Score sets each
+// candidate's Score (and TitleMatch, when a filename signal contributed);
+// the mediator sorts, cuts to MaxFileResults and derives confidence after.
+// Rankers are compared by swapping MediatorConfig.Scorer, or server-wide
+// through SetSearchScorer, rather than editing the stages.
+type Scorer interface {
+	// Name identifies the scorer in MediatorJSON diagnostics.
+	Name() string
+	Score(candidates []*Candidate, sc ScoringContext)
+}
+
+// Candidate is one collected file, or one heading section of it under
+// SearchGranularitySection, as a Scorer sees it. The fields above Score
+// describe the hits; Score, TitleMatch and Explain are the scorer's to set.
+type Candidate struct {
+	Path       string // relative to the search's home directory
+	Section    string // section key from the classifier, e.g. "components"
+	Heading    string // section results only
+	StartLine  int    // section results only: 1-based, inclusive
+	EndLine    int    // section results only; zero for whole files
+	Deprecated bool   // the deprecation registry lists the file
+
+	// TermsFound holds the query terms (or their stems and synonyms) found
+	// on the collected lines; Snippets counts those lines.
+	TermsFound map[string]bool
+	Snippets   int
+
+	Score      float64
+	TitleMatch bool
+	Explain    *ScoreExplanation // set when ScoringContext.Explain is on
+
+	lines []string
+	words int
+}
+
+// Lines returns the indexed lines the candidate covers: its section's, or
+// the whole file's. It is nil when the file is not in a search index.
+func (c *Candidate) Lines() []string { return c.lines }
+
+// Words returns the number of whitespace-separated words in Lines.
+func (c *Candidate) Words() int { return c.words }
+
+// ScoringContext is what a Scorer sees beyond the candidates themselves:
+// the query and the searched roots as one document collection.
+type ScoringContext struct {
+	QueryTerms []string
+
+	// TopicBonusPaths are the sorted path fragments the topic index ties
+	// to the query.
+	TopicBonusPaths []string
+
+	// Explain asks the scorer to set each candidate's Explain and to record
+	// its per-query decisions through RecordExplain.
+	Explain bool
+
+	corpus       *corpusStats
+	explainQuery map[string]any
+}
+
+// DocCount returns the number of distinct files in the searched roots.
+func (sc ScoringContext) DocCount() int {
+	if sc.corpus == nil {
+		return 0
+	}
+	return sc.corpus.docCount
+}
+
+// TotalWords returns the word count summed over DocCount files.
+func (sc ScoringContext) TotalWords() int {
+	if sc.corpus == nil {
+		return 0
+	}
+	return sc.corpus.totalWords
+}
+
+// DocFreq returns how many of the DocCount files contain term, matched
+// case-insensitively as a substring like the stages match.
+func (sc ScoringContext) DocFreq(term string) int {
+	if sc.corpus == nil {
+		return 0
+	}
+	return sc.corpus.docFreq(term)
+}
+
+// RecordExplain stores a per-query decision under key in
+// Diagnostics["explain"]. It does nothing unless Explain is on.
+func (sc ScoringContext) RecordExplain(key string, value any) {
+	if sc.Explain && sc.explainQuery != nil {
+		sc.explainQuery[key] = value
+	}
+}
+
+// scoreFiles runs scorer over files through their Candidate views and
+// copies back what it set.
+func scoreFiles(scorer Scorer, files []*scoredFile, sc ScoringContext) {
+	candidates := make([]*Candidate, len(files))
+	for i, sf := range files {
+		c := &Candidate{
+			Path:       sf.RelPath,
+			Section:    sf.Section,
+			Heading:    sf.Heading,
+			StartLine:  sf.StartLine,
+			EndLine:    sf.EndLine,
+			Deprecated: sf.Deprecated,
+			TermsFound: sf.TermsFound,
+			Snippets:   len(sf.Snippets),
+			Score:      sf.Score,
+			TitleMatch: sf.TitleMatch,
+		}
+		if sc.corpus != nil {
+			if doc := sc.corpus.docs[sf.AbsPath]; doc != nil {
+				c.lines, c.words = sf.lines(doc.file), doc.words
+				if sf.EndLine > 0 {
+					c.words = wordCount(c.lines)
+				}
+			}
+		}
+		candidates[i] = c
+	}
+	scorer.Score(candidates, sc)
+	for i, sf := range files {
+		c := candidates[i]
+		sf.Score, sf.TitleMatch, sf.Explain = c.Score, c.TitleMatch, c.Explain
+	}
+}
+
+// ScoreExplanation is one ranked file's score broken into the terms a
+// scorer summed, for MediatorConfig.Explain. Fields a scorer does not use
+// stay zero and are omitted.
+type ScoreExplanation struct {
+	Path    string  `json:"path"`
+	Heading string  `json:"heading,omitempty"`
+	Score   float64 `json:"score"`
+
+	// HeuristicScorer: ((coverage × section_weight) + filename_bonus +
+	// topic_bonus + density) × deprecation_multiplier.
+	Coverage      float64  `json:"coverage,omitempty"`
+	TermsFound    []string `json:"terms_found,omitempty"`
+	SectionWeight float64  `json:"section_weight"`
+	FilenameBonus float64  `json:"filename_bonus,omitempty"`
+	FilenameTerm  string   `json:"filename_term,omitempty"`
+	TopicBonus    float64  `json:"topic_bonus,omitempty"`
+	TopicSource   string   `json:"topic_source,omitempty"`
+	Density       float64  `json:"density,omitempty"`
+
+	// BM25Scorer: (sum of per-term BM25) × section_weight ×
+	// deprecation_multiplier.
+	TermScores map[string]float64 `json:"term_scores,omitempty"`
+
+	DeprecationMultiplier float64 `json:"deprecation_multiplier"`
+}
+
+// sectionWeights biases docs over source and blog posts. Both shipped
+// scorers apply it: it encodes corpus policy, not term relevance.
+var sectionWeights = map[string]float64{
+	"components": 1.5,
+	"howtos":     1.5,
+	"examples":   1.2,
+	"source":     1.0,
+	"blog":       0.8,
+	"unknown":    0.5,
+}
+
+func sectionWeight(section string) float64 {
+	if weight, ok := sectionWeights[section]; ok {
+		return weight
+	}
+	return 1.0
+}
+
+// deprecationMultiplier demotes files carrying a [!WARNING] deprecation notice.
+const deprecationMultiplier = 0.3
+
+// DefaultScorer returns the scorer used when MediatorConfig.Scorer is nil.
+func DefaultScorer() Scorer {
+	return HeuristicScorer{}
+}
+
+// scorerName is the name a nil MediatorConfig.Scorer resolves to.
+func scorerName(s Scorer) string {
+	if s == nil {
+		s = DefaultScorer()
+	}
+	return s.Name()
+}
+
+// ScorerNames lists the shipped scorers ScorerByName accepts.
+var ScorerNames = []string{"heuristic", "bm25"}
+
+// ScorerByName returns the shipped scorer called name, for flags and
+// configuration. Empty selects DefaultScorer.
+func ScorerByName(name string) (Scorer, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "":
+		return DefaultScorer(), nil
+	case "heuristic":
+		return HeuristicScorer{}, nil
+	case "bm25":
+		return BM25Scorer{}, nil
+	}
+	return nil, fmt.Errorf("unknown scorer %q (want one of: %s)", name, strings.Join(ScorerNames, ", "))
+}
+
+var (
+	searchScorerMu sync.RWMutex
+	searchScorer   Scorer
+)
+
+// SetSearchScorer sets the scorer the search tools rank with. Nil restores
+// DefaultScorer.
+func SetSearchScorer(s Scorer) {
+	searchScorerMu.Lock()
+	defer searchScorerMu.Unlock()
+	searchScorer = s
+}
+
+// SearchScorer returns the scorer set by SetSearchScorer.
+func SearchScorer() Scorer {
+	searchScorerMu.RLock()
+	defer searchScorerMu.RUnlock()
+	if searchScorer == nil {
+		return DefaultScorer()
+	}
+	return searchScorer
+}
+
+// HeuristicScorer is the original ranking: term coverage, section weight,
+// filename bonus, topic bonus, snippet density and the deprecation penalty.
+type HeuristicScorer struct{}
+
+func (HeuristicScorer) Name() string { return "heuristic" }
+
+func (HeuristicScorer) Score(candidates []*Candidate, sc ScoringContext) {
+	queryTerms := sc.QueryTerms
+	bonusEligible := filenameBonusEligible(candidates, queryTerms)
+	sc.RecordExplain("bonus_eligible", bonusEligible)
+
+	for _, c := range candidates {
+		ex := &ScoreExplanation{SectionWeight: sectionWeight(c.Section), DeprecationMultiplier: 1}
+
+		// (a) Term coverage: distinct query terms found / total query terms
+		if len(queryTerms) > 0 {
+			ex.Coverage = float64(len(c.TermsFound)) / float64(len(queryTerms))
+			c.Score += ex.Coverage
+		}
+
+		// (b) Section weight
+		c.Score *= ex.SectionWeight
+
+		// (c) Filename match bonus: token-boundary, stem-aware, and only for
+		// terms outside the generic band (#11, #27).
+		for _, term := range queryTerms {
+			if bonusEligible[term] && filenameMatchesTerm(c.Path, term) {
+				ex.FilenameBonus, ex.FilenameTerm = 2.0, term
+				c.Score += 2.0
+				c.TitleMatch = true
+				break
+			}
+		}
+
+		// (d) Topic bonus. The bonus paths are sorted, so the reported
+		// source (and which path fires first) is stable.
+		for _, bonusPath := range sc.TopicBonusPaths {
+			if strings.Contains(c.Path, bonusPath) {
+				ex.TopicBonus, ex.TopicSource = 5.0, bonusPath
+				c.Score += 5.0
+				break
+			}
+		}
+
+		// (e) Match density bonus (more snippets = more relevant)
+		ex.Density = float64(c.Snippets) * 0.1
+		c.Score += ex.Density
+
+		// (f) Deprecation penalty: demote files with [!WARNING] + "deprecated"
+		if c.Deprecated {
+			ex.DeprecationMultiplier = deprecationMultiplier
+			c.Score *= deprecationMultiplier
+		}
+
+		if sc.Explain {
+			for term := range c.TermsFound {
+				ex.TermsFound = append(ex.TermsFound, term)
+			}
+			sort.Strings(ex.TermsFound)
+			c.Explain = ex
+		}
+	}
+}
+
+// writeExplainLine renders a ScoreExplanation as the arithmetic it sums,
+// under its file in the human block.
+func writeExplainLine(out *strings.Builder, ex *ScoreExplanation) {
+	if ex == nil {
+		return
+	}
+	var parts []string
+	if ex.TermScores != nil {
+		for _, term := range sortedKeys(ex.TermScores) {
+			parts = append(parts, fmt.Sprintf("bm25(%s) %.2f", term, ex.TermScores[term]))
+		}
+		fmt.Fprintf(out, "  why: (%s) × section %.1f", strings.Join(parts, " + "), ex.SectionWeight)
+	} else {
+		parts = append(parts, fmt.Sprintf("coverage %.2f × section %.1f", ex.Coverage, ex.SectionWeight))
+		if ex.FilenameBonus > 0 {
+			parts = append(parts, fmt.Sprintf("filename %.1f (%s)", ex.FilenameBonus, ex.FilenameTerm))
+		}
+		if ex.TopicBonus > 0 {
+			parts = append(parts, fmt.Sprintf("topic %.1f (%s)", ex.TopicBonus, ex.TopicSource))
+		}
+		parts = append(parts, fmt.Sprintf("density %.1f", ex.Density))
+		fmt.Fprintf(out, "  why: (%s)", strings.Join(parts, " + "))
+	}
+	if ex.DeprecationMultiplier != 1 {
+		fmt.Fprintf(out, " × deprecated %.1f", ex.DeprecationMultiplier)
+	}
+	out.WriteString("\n")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// filenameBonusEligible computes per-term document frequency over all
+// candidates, computable because scoring runs after hit collection. The
+// filename bonus fires only for terms outside the in-corpus-generic band
+// (the #14 rule): a slug hit on "component"/"input"-class tokens is
+// coincidence, not relevance, and a flat +2.0 for them let eleven
+// generic-slug docs outrank the doc whose body restates the query (#27).
+// Small candidate sets skip the gate, as the salience band does.
+func filenameBonusEligible(candidates []*Candidate, queryTerms []string) map[string]bool {
+	bonusEligible := make(map[string]bool, len(queryTerms))
+	genericThreshold := -1
+	if len(candidates) >= 3 {
+		genericThreshold = (2*len(candidates) + 2) / 3
+	}
+	for _, term := range queryTerms {
+		if len(termStem(term)) < 4 {
+			continue
+		}
+		if genericThreshold < 0 {
+			bonusEligible[term] = true
+			continue
+		}
+		df := 0
+		for _, c := range candidates {
+			if c.TermsFound[term] {
+				df++
+			}
+		}
+		bonusEligible[term] = df < genericThreshold
+	}
+	return bonusEligible
+}
+
+// BM25Scorer ranks by Okapi BM25 over the indexed corpus: term frequency
+// in the whole file (not just the collected snippets), inverse document
+// frequency across every file of the searched roots, and length
+// normalization. Section weight and deprecation demotion still apply.
+// Zero K1/B select the conventional 1.2 and 0.75.
+type BM25Scorer struct {
+	K1 float64
+	B  float64
+}
+
+func (BM25Scorer) Name() string { return "bm25" }
+
+func (s BM25Scorer) Score(candidates []*Candidate, sc ScoringContext) {
+	k1, b := s.K1, s.B
+	if k1 <= 0 {
+		k1 = 1.2
+	}
+	if b <= 0 {
+		b = 0.75
+	}
+	terms := dedupeStrings(sc.QueryTerms)
+	n := float64(sc.DocCount())
+	if n == 0 {
+		return
+	}
+	avgLen := float64(sc.TotalWords()) / n
+	if avgLen == 0 {
+		avgLen = 1
+	}
+
+	idf := make(map[string]float64, len(terms))
+	for _, term := range terms {
+		df := float64(sc.DocFreq(term))
+		idf[term] = math.Log(1 + (n-df+0.5)/(df+0.5))
+	}
+	sc.RecordExplain("idf", idf)
+
+	for _, c := range candidates {
+		// A section result is its own document: tf and length over its lines.
+		lines := c.Lines()
+		if lines == nil {
+			continue
+		}
+		docLen := float64(c.Words())
+		ex := &ScoreExplanation{SectionWeight: sectionWeight(c.Section), DeprecationMultiplier: 1}
+		for _, term := range terms {
+			tf := float64(countTerm(lines, term))
+			if tf == 0 {
+				continue
+			}
+			contribution := idf[term] * tf * (k1 + 1) / (tf + k1*(1-b+b*docLen/avgLen))
+			if sc.Explain {
+				if ex.TermScores == nil {
+					ex.TermScores = make(map[string]float64)
+				}
+				ex.TermScores[term] = contribution
+			}
+			c.Score += contribution
+		}
+		c.Score *= ex.SectionWeight
+		if c.Deprecated {
+			ex.DeprecationMultiplier = deprecationMultiplier
+			c.Score *= deprecationMultiplier
+		}
+		if sc.Explain {
+			c.Explain = ex
+		}
+	}
+}
+
+// corpusStats exposes the searched roots' indexes as one document
+// collection: each distinct file once, with word counts for length
+// normalization.
+type corpusStats struct {
+	docs       map[string]*corpusDoc
+	indexes    []*searchIndex
+	docCount   int
+	totalWords int
+}
+
+type corpusDoc struct {
+	file  *indexedFile
+	words int
+}
+
+func newCorpusStats(roots []string, indexes map[string]*searchIndex) *corpusStats {
+	stats := &corpusStats{docs: make(map[string]*corpusDoc)}
+	for _, root := range roots {
+		idx := indexes[root]
+		if idx == nil {
+			continue
+		}
+		stats.indexes = append(stats.indexes, idx)
+		for i := range idx.Files {
+			file := &idx.Files[i]
+			if _, seen := stats.docs[file.Path]; seen {
+				continue
+			}
+			stats.docs[file.Path] = &corpusDoc{file: file, words: idx.words[i]}
+			stats.docCount++
+			stats.totalWords += idx.words[i]
+		}
+	}
+	return stats
+}
+
+// docFreq counts the distinct files with a line containing term, pruning
+// through each index's trigram postings before scanning lines.
+func (c *corpusStats) docFreq(term string) int {
+	seen := make(map[string]bool)
+	for _, idx := range c.indexes {
+		candidate := idx.candidateFiles([]string{term}, 1)
+		for i := range idx.Files {
+			file := &idx.Files[i]
+			if !candidate[i] || seen[file.Path] {
+				continue
+			}
+			if termFrequency(file, term) > 0 {
+				seen[file.Path] = true
+			}
+		}
+	}
+	return len(seen)
+}
+
+// termFrequency counts case-insensitive occurrences of term in the file,
+// the same substring basis the stages match on.
+func termFrequency(file *indexedFile, term string) int {
+	return countTerm(file.Lines, term)
+}
+
+func countTerm(lines []string, term string) int {
+	count := 0
+	for _, line := range lines {
+		count += strings.Count(strings.ToLower(line), term)
+	}
+	return count
+}
+
+func wordCount(lines []string) int {
+	n := 0
+	for _, line := range lines {
+		n += len(strings.Fields(line))
+	}
+	return n
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDefaultScorerRecordedInDiagnostics(t *testing.T) {
+	resetTopicIndexForTest(t)
+	root := t.TempDir()
+	howtoDir := filepath.Join(root, "howto")
+	if err := os.MkdirAll(howtoDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeHowtoFixture(t, howtoDir, "a.md", "# Alpha\nselection sync\n")
+
+	_, summary, err := ExecuteMediatedSearch(root, howtoMediatorConfig(howtoDir), "selection")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Diagnostics["scorer"] != "heuristic" {
+		t.Fatalf("expected heuristic scorer in diagnostics, got %v", summary.Diagnostics["scorer"])
+	}
+}
+
+// BM25 weighs whole-file term frequency against document length, so a
+// short doc about the term outranks a long one that mentions it in passing.
+func TestBM25ScorerPrefersDenseShortDocs(t *testing.T) {
+	resetTopicIndexForTest(t)
+	root := t.TempDir()
+	howtoDir := filepath.Join(root, "howto")
+	if err := os.MkdirAll(howtoDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	long := "# Notes\nThe tilegrid appears once here.\n"
+	for i := 0; i < 40; i++ {
+		long += "Filler prose about unrelated layout concerns and other things.\n"
+	}
+	writeHowtoFixture(t, howtoDir, "notes.md", long)
+	writeHowtoFixture(t, howtoDir, "grid.md", "# Grid\nThe tilegrid renders tiles.\nEach tilegrid item is a tile.\n")
+	writeHowtoFixture(t, howtoDir, "other.md", "# Other\nNothing relevant.\n")
+
+	cfg := howtoMediatorConfig(howtoDir)
+	cfg.Scorer = BM25Scorer{}
+	_, summary, err := ExecuteMediatedSearch(root, cfg, "tilegrid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Diagnostics["scorer"] != "bm25" {
+		t.Fatalf("expected bm25 scorer in diagnostics, got %v", summary.Diagnostics["scorer"])
+	}
+	items := summary.Sections["howtos"]
+	if len(items) == 0 || filepath.Base(items[0].Path) != "grid.md" {
+		t.Fatalf("expected grid.md ranked first under BM25, got %+v", items)
+	}
+}
+
+func TestExplainRecordsScoreBreakdown(t *testing.T) {
+	resetTopicIndexForTest(t)
+	root := t.TempDir()
+	howtoDir := filepath.Join(root, "howto")
+	if err := os.MkdirAll(howtoDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeHowtoFixture(t, howtoDir, "tooltip.md", "# Tooltip\nShow a tooltip on hover.\n")
+	writeHowtoFixture(t, howtoDir, "styles.md", "# Styles\nA tooltip can appear on hover.\n")
+
+	cfg := howtoMediatorConfig(howtoDir)
+	cfg.Explain = true
+	human, summary, err := ExecuteMediatedSearch(root, cfg, "tooltip hover")
+	if err != nil {
+		t.Fatal(err)
+	}
+	explain, ok := summary.Diagnostics["explain"].(map[string]any)
+	if !ok {
+		t.Fatalf("no explain diagnostics: %v", summary.Diagnostics)
+	}
+	if _, ok := explain["bonus_eligible"].(map[string]bool); !ok {
+		t.Fatalf("bonus_eligible missing: %v", explain)
+	}
+	files := explain["files"].([]ScoreExplanation)
+	if len(files) != 2 || files[0].Path != filepath.Join("howto", "tooltip.md") {
+		t.Fatalf("files = %+v", files)
+	}
+	top := files[0]
+	want := (top.Coverage*top.SectionWeight + top.FilenameBonus + top.TopicBonus + top.Density) * top.DeprecationMultiplier
+	if top.Coverage != 1 || top.FilenameTerm != "tooltip" || math.Abs(top.Score-want) > 1e-9 {
+		t.Fatalf("breakdown %+v does not sum to its score", top)
+	}
+	if !strings.Contains(human, "  why: (coverage 1.00 × section 1.5 + filename 2.0 (tooltip) + density") {
+		t.Fatalf("human block lacks the breakdown:\n%s", human)
+	}
+
+	cfg.Explain = false
+	_, summary, err = ExecuteMediatedSearch(root, cfg, "tooltip hover")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := summary.Diagnostics["explain"]; ok {
+		t.Fatal("explain diagnostics without Explain")
+	}
+}
+
+// pathLengthScorer ranks shorter paths first using only the exported
+// Candidate and ScoringContext surface, as a scorer outside the package would.
+type pathLengthScorer struct{}
+
+func (pathLengthScorer) Name() string { return "path-length" }
+
+func (pathLengthScorer) Score(candidates []*Candidate, sc ScoringContext) {
+	sc.RecordExplain("docs", sc.DocCount())
+	for _, c := range candidates {
+		if len(c.Lines()) == 0 || c.Words() == 0 {
+			continue
+		}
+		c.Score = 1 / float64(len(c.Path))
+	}
+}
+
+func TestCustomScorerRanksThroughExportedView(t *testing.T) {
+	resetTopicIndexForTest(t)
+	root := t.TempDir()
+	howtoDir := filepath.Join(root, "howto")
+	if err := os.MkdirAll(howtoDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeHowtoFixture(t, howtoDir, "tilegrid-in-depth.md", "# Tilegrid\nThe tilegrid renders tiles.\nEach tilegrid item is a tile.\n")
+	writeHowtoFixture(t, howtoDir, "grid.md", "# Grid\nA tilegrid.\n")
+
+	cfg := howtoMediatorConfig(howtoDir)
+	cfg.Scorer = pathLengthScorer{}
+	cfg.Explain = true
+	_, summary, err := ExecuteMediatedSearch(root, cfg, "tilegrid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Diagnostics["scorer"] != "path-length" {
+		t.Fatalf("scorer = %v", summary.Diagnostics["scorer"])
+	}
+	items := summary.Sections["howtos"]
+	if len(items) == 0 || filepath.Base(items[0].Path) != "grid.md" {
+		t.Fatalf("expected grid.md first under the custom scorer, got %+v", items)
+	}
+	if explain := summary.Diagnostics["explain"].(map[string]any); explain["docs"] != 2 {
+		t.Fatalf("explain = %v", explain)
+	}
+}
+
+func TestSearchScorerSelectsByName(t *testing.T) {
+	t.Cleanup(func() { SetSearchScorer(nil) })
+	if SearchScorer().Name() != "heuristic" {
+		t.Fatalf("default = %s", SearchScorer().Name())
+	}
+	scorer, err := ScorerByName("BM25")
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetSearchScorer(scorer)
+	if SearchScorer().Name() != "bm25" {
+		t.Fatalf("selected = %s", SearchScorer().Name())
+	}
+	if _, err := ScorerByName("pagerank"); err == nil {
+		t.Fatal("unknown scorer name accepted")
+	}
+}
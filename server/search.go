@@ -2,19 +2,38 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"path/filepath"
 	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
+// searchSectionKeys are the sections xmlui_search reports and filters on.
+var searchSectionKeys = []string{"components", "howtos", "examples", "source", "blog"}
+
 // NewSearchTool wires xmlui_search to the shared search mediator.
 // exampleRoots are optional paths outside homeDir that should be classified as "examples".
 func NewSearchTool(homeDir string, exampleRoots []string) (mcp.Tool, func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)) {
 	tool := mcp.NewTool(
 		"xmlui_search",
-		mcp.WithDescription("Searches XMLUI source, docs, and examples using a staged search mediator. Returns human-readable matches plus a JSON summary."),
-		mcp.WithString("query", mcp.Required(), mcp.Description("Search term, e.g. 'Slider', 'boxShadow', or 'pathname context variable'")),
+		mcp.WithDescription("Searches XMLUI source, docs, and examples using a staged search mediator. Returns human-readable matches; pass format 'json' or 'both' for the structured MediatorJSON summary."),
+		mcp.WithString("query", mcp.Required(), mcp.Description("Search term, e.g. 'Slider', 'boxShadow', or 'pathname context variable'"+queryGrammarHint)),
+		withSearchFormat(),
+		withSearchOffset(),
+		withSearchCursor(),
+		withSearchContextLines(),
+		withSearchFuzzy(),
+		withSearchExplain(),
+		withSearchHighlight(),
+		withSearchGranularity(),
+		withSearchSections(searchSectionKeys),
+		withSearchInclude(),
+		withSearchExclude(),
+		mcp.WithString("mode",
+			mcp.Enum(SearchModeWords, SearchModeRegex),
+			mcp.Description("Optional match mode. 'words' (default) runs the staged word search; 'regex' treats query as a Go (RE2) regular expression, e.g. 'use[A-Z]\\w+Event' or 'createMetadata\\(', and lists matching lines in walk order up to a match budget. Regex mode does not page."),
+		),
 	)
 
 	tool.Annotations = mcp.ToolAnnotation{
@@ -30,34 +49,101 @@ func NewSearchTool(homeDir string, exampleRoots []string) (mcp.Tool, func(contex
 			return mcp.NewToolResultError("Missing or invalid 'query' parameter"), nil
 		}
 		query := strings.TrimSpace(raw)
+		format, err := searchFormatArgument(req)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		contextLines, err := searchContextLinesArgument(req)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		fuzzy, err := searchBoolArgument(req, "fuzzy", false)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		explain, err := searchBoolArgument(req, "explain", false)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		highlight, err := searchBoolArgument(req, "highlight", false)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		granularity, err := searchGranularityArgument(req)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		sections, include, exclude, err := searchFilterArguments(req, searchSectionKeys)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		mode, _ := req.Params.Arguments["mode"].(string)
+		switch mode {
+		case "", SearchModeWords:
+			mode = ""
+		case SearchModeRegex:
+		default:
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid 'mode' parameter %q: use 'words' or 'regex'", mode)), nil
+		}
 
-		// Repository roots to scan (order matters for biasing)
+		// Repository roots to scan (order matters for biasing), each with the
+		// sections SimpleClassifier can assign beneath it. Pages holds the
+		// how-tos; example roots may sit inside any of them.
 		paths := GetRepoPaths(homeDir)
-		roots := []string{
-			filepath.Join(homeDir, paths.ComponentDocs),
-			filepath.Join(homeDir, paths.Pages),
-			filepath.Join(homeDir, paths.ComponentSource),
-			filepath.Join(homeDir, paths.Blog),
+		candidates := []struct {
+			dir      string
+			sections []string
+		}{
+			{paths.ComponentDocs, []string{"components", "examples"}},
+			{paths.Pages, []string{"components", "howtos", "examples"}},
+			{paths.ComponentSource, []string{"source", "examples"}},
+			{paths.Blog, []string{"blog", "examples"}},
+		}
+		var roots []string
+		for _, c := range candidates {
+			if len(sections) > 0 && !sharesString(sections, c.sections) {
+				continue
+			}
+			roots = append(roots, filepath.Join(homeDir, c.dir))
 		}
 
+		dict := CurrentSearchDictionary()
 		cfg := MediatorConfig{
 			Roots:                 roots,
-			SectionKeys:           []string{"components", "howtos", "examples", "source", "blog"},
+			SectionKeys:           searchSectionKeys,
 			PreferSections:        []string{"components", "howtos"}, // bias docs/howtos when expanding
 			MaxResults:            50,
 			FileExtensions:        []string{".mdx", ".md", ".tsx", ".scss"},
-			Stopwords:             DefaultStopwords(),
-			Synonyms:              DefaultSynonyms(),
+			Stopwords:             dict.Stopwords,
+			Synonyms:              dict.Synonyms,
 			Classifier:            SimpleClassifier(homeDir, exampleRoots),
 			EnableFilenameMatches: true,
 			ToolName:              "xmlui_search",
+			ContextLines:          contextLines,
+			Timeout:               SearchTimeout(),
+			Scorer:                SearchScorer(),
+			FuzzyTokens:           fuzzy,
+			Explain:               explain,
+			Highlight:             highlight,
+			Granularity:           granularity,
+			Sections:              sections,
+			Include:               include,
+			Exclude:               exclude,
+			Mode:                  mode,
+		}
+		cfg.Offset, err = searchPagingArgument(req, cfg, query)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if mode == SearchModeRegex && cfg.Offset > 0 {
+			return mcp.NewToolResultError("Regex mode does not page: narrow the pattern or the sections/include filters instead of passing offset or cursor"), nil
 		}
 
-		human, err := ExecuteMediatedSearchWithAnalytics(ctx, "xmlui_search", homeDir, cfg, query)
+		human, summary, err := ExecuteMediatedSearchWithAnalytics(ctx, "xmlui_search", homeDir, cfg, query)
 		if err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
-		return mcp.NewToolResultText(human), nil
+		return searchToolResult(format, human, summary), nil
 	}
 
 	return tool, handler
@@ -0,0 +1,189 @@
+package server
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// searchCacheSize bounds the query cache. Entries are one rendered page of
+// results each, so a few hundred cost a few megabytes at most.
+const searchCacheSize = 256
+
+// searchCache is an LRU of mediated search results. Agents repeat the same
+// searches many times a session, and a hit skips every stage and the
+// scoring pass. Keys pin everything a result depends on (see
+// searchCacheKey), so entries are never stale, only evicted; Purge drops
+// them all when the corpus changes. Related queries follow analytics
+// rather than the corpus, and are recomputed on every hit.
+type searchCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // front is most recently used
+	entries  map[string]*list.Element
+	hits     int
+	misses   int
+}
+
+type searchCacheEntry struct {
+	key       string
+	human     string
+	summary   MediatorJSON
+	headings  []string // see mediatedResult
+	relatedAt int
+}
+
+var queryCache = newSearchCache(searchCacheSize)
+
+func newSearchCache(capacity int) *searchCache {
+	return &searchCache{capacity: capacity, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+func (c *searchCache) get(key string) (searchCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return searchCacheEntry{}, false
+	}
+	c.hits++
+	c.order.MoveToFront(el)
+	return el.Value.(searchCacheEntry), true
+}
+
+func (c *searchCache) put(entry searchCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[entry.key]; ok {
+		el.Value = entry
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[entry.key] = c.order.PushFront(entry)
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(searchCacheEntry).key)
+	}
+}
+
+// Purge drops every entry. Hit and miss counts survive: they describe the
+// session, not the current corpus.
+func (c *searchCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	c.entries = map[string]*list.Element{}
+}
+
+// stats reports the counters GetAnalyticsSummary surfaces.
+func (c *searchCache) stats() (hits, misses, entries int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses, c.order.Len()
+}
+
+// ResetSearchCache empties the query cache and zeroes its counters, for
+// testing.
+func ResetSearchCache() {
+	queryCache.Purge()
+	queryCache.mu.Lock()
+	queryCache.hits, queryCache.misses = 0, 0
+	queryCache.mu.Unlock()
+}
+
+// cachedMediatedSearch is ExecuteMediatedSearchContext behind queryCache.
+// The lookup and a missed search share one resolution of the roots'
+// indexes, under the search's time budget. Results cut short by ctx or the
+// budget are not cached, so a retry gets a full search. A hit returns the
+// stored summary itself, with fresh related queries; callers only read it.
+func cachedMediatedSearch(ctx context.Context, homeDir string, cfg MediatorConfig, query string) (string, MediatorJSON, error) {
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+	}
+	indexSet := newSearchIndexSet(homeDir, searchCacheExtensions(cfg))
+	key, ok := searchCacheKey(ctx, homeDir, cfg, query, indexSet)
+	if ok {
+		if entry, hit := queryCache.get(key); hit {
+			return entry.withRelated(relatedQueries(query, entry.headings))
+		}
+	}
+	res, err := executeMediatedSearch(ctx, homeDir, cfg, query, indexSet)
+	if ok && err == nil && res.summary.Diagnostics["truncated"] != true {
+		queryCache.put(searchCacheEntry{key: key, human: res.human, summary: res.summary, headings: res.headings, relatedAt: res.relatedAt})
+	}
+	return res.human, res.summary, err
+}
+
+// withRelated returns the entry's result with its related queries replaced
+// by related, in the summary and in the human block's line.
+func (e searchCacheEntry) withRelated(related []string) (string, MediatorJSON, error) {
+	if slices.Equal(related, e.summary.RelatedQueries) {
+		return e.human, e.summary, nil
+	}
+	summary := e.summary
+	summary.RelatedQueries = related
+	if e.relatedAt < 0 {
+		return e.human, summary, nil
+	}
+	var stale, fresh strings.Builder
+	writeRelatedLine(&stale, e.summary)
+	writeRelatedLine(&fresh, summary)
+	return e.human[:e.relatedAt] + fresh.String() + e.human[e.relatedAt+stale.Len():], summary, nil
+}
+
+// searchCacheKey hashes what a search result depends on: the corpus stamp,
+// the query, a fingerprint of cfg and the generation of every root's index.
+// Example roots are refreshed as the search would refresh them, through
+// indexSet, so an edited example file yields a new generation and misses
+// while the search that follows reuses the refresh. ok is false when ctx
+// ended before every index was resolved.
+func searchCacheKey(ctx context.Context, homeDir string, cfg MediatorConfig, query string, indexSet *searchIndexSet) (string, bool) {
+	generations := make([]uint64, len(cfg.Roots))
+	for i, idx := range indexSet.resolve(ctx, cfg.Roots) {
+		if idx != nil {
+			generations[i] = idx.generation
+		}
+	}
+	if ctx.Err() != nil {
+		return "", false
+	}
+
+	globalCorpusStampMu.Lock()
+	stamp := globalCorpusStamp
+	globalCorpusStampMu.Unlock()
+
+	// The query is keyed as typed: "OR" is an operator and "or" a stopword,
+	// and the human block and Diagnostics echo the query, so folding even
+	// case would hand one caller another's results or text.
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00%v", stamp, homeDir, strings.TrimSpace(query), configFingerprint(cfg), generations)
+	return hex.EncodeToString(h.Sum(nil)), true
+}
+
+// searchCacheExtensions mirrors ExecuteMediatedSearchContext's default, so
+// the lookup resolves the same index the search then uses.
+func searchCacheExtensions(cfg MediatorConfig) []string {
+	if len(cfg.FileExtensions) == 0 {
+		return []string{".mdx", ".md", ".tsx", ".scss"}
+	}
+	return cfg.FileExtensions
+}
+
+// configFingerprint renders every MediatorConfig field that changes a
+// result. Classifier is a closure and cannot be compared; the tool name and
+// roots already determine which classifier a tool builds. Timeout only
+// decides whether a result is complete, and incomplete ones are not cached.
+func configFingerprint(cfg MediatorConfig) string {
+	cfg.Classifier = nil
+	cfg.Timeout = 0
+	return fmt.Sprintf("%#v", cfg)
+}
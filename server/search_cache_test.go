@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSearchCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := newSearchCache(2)
+	c.put(searchCacheEntry{key: "a", human: "A"})
+	c.put(searchCacheEntry{key: "b", human: "B"})
+	if _, ok := c.get("a"); !ok {
+		t.Fatal("a missing")
+	}
+	c.put(searchCacheEntry{key: "c", human: "C"})
+	if _, ok := c.get("b"); ok {
+		t.Fatal("b survived; it was the least recently used")
+	}
+	if entry, ok := c.get("a"); !ok || entry.human != "A" {
+		t.Fatalf("a = %+v, %v", entry, ok)
+	}
+	if hits, misses, entries := c.stats(); hits != 2 || misses != 1 || entries != 2 {
+		t.Fatalf("stats = %d hits, %d misses, %d entries", hits, misses, entries)
+	}
+}
+
+func TestSearchCacheHitsRepeatsAndMissesAfterEdits(t *testing.T) {
+	resetTopicIndexForTest(t)
+	ResetSearchCache()
+	t.Cleanup(ResetSearchCache)
+	useTestAnalytics(t)
+	root := t.TempDir()
+	howtoDir := filepath.Join(root, "howto")
+	if err := os.MkdirAll(howtoDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeHowtoFixture(t, howtoDir, "modal.md", "# Open a modal dialog\nUse ModalDialog.\n")
+	cfg := howtoMediatorConfig(howtoDir)
+	search := func(query string) string {
+		t.Helper()
+		human, _, err := ExecuteMediatedSearchWithAnalytics(context.Background(), "xmlui_search_howto", root, cfg, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return human
+	}
+
+	first := search("modal dialog")
+	if again := search("modal dialog"); again != first {
+		t.Fatalf("repeat was not served from the cache:\n%s", again)
+	}
+	// The query is echoed, so another casing is its own entry.
+	if other := search("Modal Dialog"); !strings.Contains(other, `Query: "Modal Dialog"`) {
+		t.Fatalf("another casing was served the first caller's text:\n%s", other)
+	}
+	cfg.MaxResults = 1
+	search("modal dialog")
+	if hits, misses, _ := queryCache.stats(); hits != 1 || misses != 3 {
+		t.Fatalf("after a config change: %d hits, %d misses; want 1, 3", hits, misses)
+	}
+
+	writeHowtoFixture(t, howtoDir, "modal.md", "# Open a modal dialog\nUse ModalDialog with isInitiallyOpen.\n")
+	if human := search("modal dialog"); !strings.Contains(human, "isInitiallyOpen") {
+		t.Fatalf("edited file served from a stale entry:\n%s", human)
+	}
+
+	summary := GetAnalyticsSummary()
+	if summary["search_cache_hits"] != 1 || summary["search_cache_misses"] != 4 || summary["search_cache_hit_rate"] != 20.0 {
+		t.Fatalf("summary cache counters = %v/%v/%v", summary["search_cache_hits"], summary["search_cache_misses"], summary["search_cache_hit_rate"])
+	}
+}
+
+// "OR" is an operator and "or" a stopword: the two queries must not share
+// an entry.
+func TestSearchCacheKeepsOperatorCase(t *testing.T) {
+	resetTopicIndexForTest(t)
+	ResetSearchCache()
+	t.Cleanup(ResetSearchCache)
+	useTestAnalytics(t)
+	root := t.TempDir()
+	howtoDir := filepath.Join(root, "howto")
+	if err := os.MkdirAll(howtoDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeHowtoFixture(t, howtoDir, "click.md", "# Clicks\nHandle onClick here.\n")
+	writeHowtoFixture(t, howtoDir, "double.md", "# Double clicks\nHandle onDoubleClick here.\n")
+	cfg := howtoMediatorConfig(howtoDir)
+
+	_, either, err := ExecuteMediatedSearchWithAnalytics(context.Background(), "xmlui_search_howto", root, cfg, "onClick OR onDoubleClick")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, both, err := ExecuteMediatedSearchWithAnalytics(context.Background(), "xmlui_search_howto", root, cfg, "onClick or onDoubleClick")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hits, _, _ := queryCache.stats(); hits != 0 {
+		t.Fatalf("%d cache hits; the two queries must not share an entry", hits)
+	}
+	if either.QueryPlan[0].Query == both.QueryPlan[0].Query {
+		t.Fatalf("both queries parsed as %q", both.QueryPlan[0].Query)
+	}
+}
+
+// Related queries lead with analytics reformulations, which change after a
+// result is cached; a hit recomputes them.
+func TestSearchCacheHitRefreshesRelatedQueries(t *testing.T) {
+	resetTopicIndexForTest(t)
+	ResetSearchCache()
+	t.Cleanup(ResetSearchCache)
+	a := useTestAnalytics(t)
+	root := t.TempDir()
+	howtoDir := filepath.Join(root, "howto")
+	if err := os.MkdirAll(howtoDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeHowtoFixture(t, howtoDir, "layout.md", "# Layout\nStack children vertically.\n")
+	cfg := howtoMediatorConfig(howtoDir)
+
+	human, summary, err := ExecuteMediatedSearchWithAnalytics(context.Background(), "xmlui_search_howto", root, cfg, "paginate rows")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summary.RelatedQueries) != 0 || strings.Contains(human, "Related queries") {
+		t.Fatalf("unexpected related queries before any reformulation: %v", summary.RelatedQueries)
+	}
+
+	recordSearchForTest(a, time.Now(), "Table pageSize", true)
+	human, summary, err = ExecuteMediatedSearchWithAnalytics(context.Background(), "xmlui_search_howto", root, cfg, "paginate rows")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hits, _, _ := queryCache.stats(); hits != 1 {
+		t.Fatalf("%d cache hits, want 1", hits)
+	}
+	if len(summary.RelatedQueries) != 1 || summary.RelatedQueries[0] != "Table pageSize" {
+		t.Fatalf("related = %v, want the new reformulation", summary.RelatedQueries)
+	}
+	if !strings.Contains(human, "Related queries: Table pageSize\n") {
+		t.Fatalf("cached human block lacks the new related queries:\n%s", human)
+	}
+}
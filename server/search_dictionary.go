@@ -0,0 +1,159 @@
+package server
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// searchDictionaryName is the base name of a search dictionary file. The
+// corpus may ship one beside mcp-paths.json, and a user may keep one in
+// their config directory; each is read as .json, .yaml or .yml, first
+// found wins.
+const searchDictionaryName = "mcp-search"
+
+// SearchDictionary is the stopword and synonym vocabulary the search tools
+// hand to MediatorConfig. Keys and values are lowercase.
+type SearchDictionary struct {
+	Stopwords map[string]struct{}
+	Synonyms  map[string][]string
+}
+
+// searchDictionaryFile is the on-disk shape:
+//
+//	stopwords: [example, usage, the]
+//	synonyms:
+//	  modal: [ModalDialog]
+//	  grid: [Table]
+//
+// A file that lists stopwords replaces the list below it; omit the key to
+// keep it. Synonym entries replace the entry for the same word, and an
+// empty list removes it.
+type searchDictionaryFile struct {
+	Stopwords []string            `json:"stopwords" yaml:"stopwords"`
+	Synonyms  map[string][]string `json:"synonyms" yaml:"synonyms"`
+}
+
+var (
+	searchDictMu        sync.Mutex
+	searchDictCorpusDir string
+	searchDictUserDir   string
+	searchDict          *SearchDictionary
+)
+
+// SetSearchDictionaryDirs names the directories searched for dictionary
+// files: the corpus checkout and the user's config directory. Either may be
+// empty. Layers apply in order: built-in defaults, corpus, user.
+func SetSearchDictionaryDirs(corpusDir, userDir string) {
+	searchDictMu.Lock()
+	defer searchDictMu.Unlock()
+	searchDictCorpusDir, searchDictUserDir = corpusDir, userDir
+	searchDict = nil
+}
+
+// CurrentSearchDictionary returns the layered dictionary, loading it on
+// first use. The maps are shared; callers must not modify them.
+func CurrentSearchDictionary() *SearchDictionary {
+	searchDictMu.Lock()
+	defer searchDictMu.Unlock()
+	if searchDict == nil {
+		searchDict = loadSearchDictionary(searchDictCorpusDir, searchDictUserDir)
+	}
+	return searchDict
+}
+
+func loadSearchDictionary(dirs ...string) *SearchDictionary {
+	dict := &SearchDictionary{Stopwords: DefaultStopwords(), Synonyms: DefaultSynonyms()}
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		file, path := readSearchDictionaryFile(dir)
+		if file == nil {
+			continue
+		}
+		WriteDebugLog("Loaded search dictionary from %s\n", path)
+		if file.Stopwords != nil {
+			dict.Stopwords = make(map[string]struct{}, len(file.Stopwords))
+			for _, w := range file.Stopwords {
+				dict.Stopwords[strings.ToLower(strings.TrimSpace(w))] = struct{}{}
+			}
+		}
+		for word, alternatives := range file.Synonyms {
+			word = strings.ToLower(strings.TrimSpace(word))
+			if len(alternatives) == 0 {
+				delete(dict.Synonyms, word)
+				continue
+			}
+			lowered := make([]string, 0, len(alternatives))
+			for _, alt := range alternatives {
+				if alt = strings.ToLower(strings.TrimSpace(alt)); alt != "" && alt != word {
+					lowered = append(lowered, alt)
+				}
+			}
+			dict.Synonyms[word] = dedupeStrings(lowered)
+		}
+	}
+	return dict
+}
+
+// readSearchDictionaryFile reads the first dictionary file present in dir.
+// A malformed file is logged and skipped, leaving the layers below intact.
+func readSearchDictionaryFile(dir string) (*searchDictionaryFile, string) {
+	for _, ext := range []string{".json", ".yaml", ".yml"} {
+		path := filepath.Join(dir, searchDictionaryName+ext)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var file searchDictionaryFile
+		if ext == ".json" {
+			err = json.Unmarshal(data, &file)
+		} else {
+			err = yaml.Unmarshal(data, &file)
+		}
+		if err != nil {
+			WriteDebugLog("Failed to parse search dictionary %s: %v\n", path, err)
+			return nil, path
+		}
+		return &file, path
+	}
+	return nil, ""
+}
+
+// synonymVariants returns copies of tokens with one token swapped for one
+// of its synonyms, in token then synonym order, at most limit of them. The
+// relaxed and partial stages run each variant as an extra query, so "grid"
+// also finds lines about Table without requiring both words.
+func synonymVariants(tokens []string, synonyms map[string][]string, limit int) [][]string {
+	var out [][]string
+	for i, token := range tokens {
+		for _, alt := range synonyms[token] {
+			if len(out) == limit {
+				return out
+			}
+			variant := append([]string{}, tokens...)
+			variant[i] = alt
+			out = append(out, variant)
+		}
+	}
+	return out
+}
+
+// containsTermOrSynonym reports whether lowered text contains term or any
+// of its synonyms, so a synonym hit covers the term it stands in for.
+func containsTermOrSynonym(lowered, term string, synonyms map[string][]string) bool {
+	if strings.Contains(lowered, term) {
+		return true
+	}
+	for _, alt := range synonyms[term] {
+		if strings.Contains(lowered, alt) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSearchDictionaryLayersCorpusAndUserFiles(t *testing.T) {
+	corpus, user := t.TempDir(), t.TempDir()
+	corpusYAML := "stopwords: [the, Example]\nsynonyms:\n  grid: [Table, DataGrid]\n  modal: [Dialog]\n"
+	if err := os.WriteFile(filepath.Join(corpus, "mcp-search.yaml"), []byte(corpusYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	userJSON := `{"synonyms": {"grid": ["List"], "dropdown": []}}`
+	if err := os.WriteFile(filepath.Join(user, "mcp-search.json"), []byte(userJSON), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	dict := loadSearchDictionary(corpus, user)
+	if !reflect.DeepEqual(dict.Stopwords, map[string]struct{}{"the": {}, "example": {}}) {
+		t.Fatalf("stopwords = %v", dict.Stopwords)
+	}
+	if !reflect.DeepEqual(dict.Synonyms["grid"], []string{"list"}) {
+		t.Fatalf("user entry did not replace corpus entry: %v", dict.Synonyms["grid"])
+	}
+	if !reflect.DeepEqual(dict.Synonyms["modal"], []string{"dialog"}) {
+		t.Fatalf("corpus entry lost: %v", dict.Synonyms["modal"])
+	}
+	if _, ok := dict.Synonyms["dropdown"]; ok {
+		t.Fatal("empty user list did not remove the built-in entry")
+	}
+	if _, ok := dict.Synonyms["calendar"]; !ok {
+		t.Fatal("built-in entry lost")
+	}
+
+	bare := loadSearchDictionary("", t.TempDir())
+	if !reflect.DeepEqual(bare.Stopwords, DefaultStopwords()) || !reflect.DeepEqual(bare.Synonyms, DefaultSynonyms()) {
+		t.Fatal("without files the built-in defaults should apply")
+	}
+}
+
+func TestSynonymsExpandRelaxedAndPartialStages(t *testing.T) {
+	resetTopicIndexForTest(t)
+	root := t.TempDir()
+	howtoDir := filepath.Join(root, "howto")
+	if err := os.MkdirAll(howtoDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeHowtoFixture(t, howtoDir, "rows.md", "# Show rows\nA Table with sortable columns.\n")
+
+	cfg := howtoMediatorConfig(howtoDir)
+	cfg.Synonyms = map[string][]string{}
+	_, summary, err := ExecuteMediatedSearch(root, cfg, "sortable grid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	noSynonyms := len(summary.Sections["howtos"])
+
+	cfg.Synonyms = map[string][]string{"grid": {"table"}}
+	_, summary, err = ExecuteMediatedSearch(root, cfg, "sortable grid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if noSynonyms != 0 || len(summary.Sections["howtos"]) == 0 {
+		t.Fatalf("results without/with synonyms = %d/%d, want 0/>0", noSynonyms, len(summary.Sections["howtos"]))
+	}
+	if !reflect.DeepEqual(summary.Tokens["expanded"], []string{"sortable", "grid", "table"}) {
+		t.Fatalf("expanded = %v", summary.Tokens["expanded"])
+	}
+}
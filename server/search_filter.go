@@ -0,0 +1,295 @@
+package server
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// searchFilter restricts a mediated search to some sections and to paths
+// matching include/exclude globs. Paths are repo-relative with forward
+// slashes, exactly as results report them, so an agent can narrow a search
+// by pasting a path it was shown.
+type searchFilter struct {
+	sections   map[string]bool
+	include    []*regexp.Regexp
+	exclude    []*regexp.Regexp
+	components map[string]bool // lowercased; from component: query fields
+	exts       map[string]bool // lowercased with dot; from ext: query fields
+}
+
+// newSearchFilter compiles the MediatorConfig filter fields. It returns nil
+// when nothing is filtered, so the unfiltered search pays nothing.
+func newSearchFilter(sections, include, exclude []string) (*searchFilter, error) {
+	if len(sections) == 0 && len(include) == 0 && len(exclude) == 0 {
+		return nil, nil
+	}
+	f := &searchFilter{}
+	if len(sections) > 0 {
+		f.sections = make(map[string]bool, len(sections))
+		for _, s := range sections {
+			f.sections[s] = true
+		}
+	}
+	var err error
+	if f.include, err = compileGlobs(include); err != nil {
+		return nil, err
+	}
+	if f.exclude, err = compileGlobs(exclude); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// allowsFile reports whether a file classified into section passes.
+func (f *searchFilter) allowsFile(rel, section string) bool {
+	if f == nil {
+		return true
+	}
+	if f.sections != nil && !f.sections[section] {
+		return false
+	}
+	rel = strings.ReplaceAll(rel, "\\", "/")
+	if f.exts != nil && !f.exts[strings.ToLower(path.Ext(rel))] {
+		return false
+	}
+	if f.components != nil && !pathNamesComponent(rel, f.components) {
+		return false
+	}
+	if matchesAnyGlob(f.exclude, rel) {
+		return false
+	}
+	return len(f.include) == 0 || matchesAnyGlob(f.include, rel)
+}
+
+// withQueryFields narrows f by a parsed query's section:, ext: and
+// component: fields. Sections intersect with the tool argument's, so a
+// field can narrow a search but never widen it. f may be nil.
+func (f *searchFilter) withQueryFields(pq ParsedQuery) *searchFilter {
+	if len(pq.Fields) == 0 {
+		return f
+	}
+	if f == nil {
+		f = &searchFilter{}
+	}
+	if sections := pq.Fields["section"]; len(sections) > 0 {
+		narrowed := map[string]bool{}
+		for _, s := range sections {
+			s = strings.ToLower(s)
+			if f.sections == nil || f.sections[s] {
+				narrowed[s] = true
+			}
+		}
+		f.sections = narrowed
+	}
+	if exts := pq.Fields["ext"]; len(exts) > 0 {
+		f.exts = map[string]bool{}
+		for _, e := range exts {
+			f.exts["."+strings.TrimPrefix(strings.ToLower(e), ".")] = true
+		}
+	}
+	if components := pq.Fields["component"]; len(components) > 0 {
+		f.components = map[string]bool{}
+		for _, c := range components {
+			f.components[strings.ToLower(c)] = true
+		}
+	}
+	return f
+}
+
+// pathNamesComponent reports whether a directory or the file stem of rel
+// names one of components (e.g. Button/Button.tsx or Button.md).
+func pathNamesComponent(rel string, components map[string]bool) bool {
+	segments := strings.Split(rel, "/")
+	last := len(segments) - 1
+	segments[last] = strings.TrimSuffix(segments[last], path.Ext(segments[last]))
+	for _, seg := range segments {
+		if components[strings.ToLower(seg)] {
+			return true
+		}
+	}
+	return false
+}
+
+// excludesRoot reports whether an exclude pattern covers every path below
+// the root (e.g. "blog/**"), so the root can be skipped without a scan. The
+// probe path is two levels deep in names no real pattern spells.
+func (f *searchFilter) excludesRoot(rootRel string) bool {
+	if f == nil || len(f.exclude) == 0 {
+		return false
+	}
+	probe := strings.ReplaceAll(rootRel, "\\", "/") + "/\uffff/\uffff"
+	return matchesAnyGlob(f.exclude, probe)
+}
+
+// pruneRoots drops the roots excludesRoot covers. f may be nil.
+func (f *searchFilter) pruneRoots(homeDir string, roots []string) []string {
+	if f == nil {
+		return roots
+	}
+	kept := make([]string, 0, len(roots))
+	for _, root := range roots {
+		if rel, err := filepath.Rel(homeDir, root); err == nil && f.excludesRoot(rel) {
+			continue
+		}
+		kept = append(kept, root)
+	}
+	return kept
+}
+
+func matchesAnyGlob(globs []*regexp.Regexp, rel string) bool {
+	for _, re := range globs {
+		if re.MatchString(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+func compileGlobs(patterns []string) ([]*regexp.Regexp, error) {
+	var out []*regexp.Regexp
+	for _, p := range patterns {
+		re, err := globToRegexp(p)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, re)
+	}
+	return out, nil
+}
+
+// globToRegexp compiles a path glob: "*" and "?" stay within one path
+// segment, "**" spans segments. A pattern without "/" is matched against the
+// base name, as .gitignore does, so "*.tsx" needs no directory prefix.
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	p := strings.Trim(strings.ReplaceAll(strings.TrimSpace(pattern), "\\", "/"), "/")
+	if p == "" {
+		return nil, fmt.Errorf("empty glob pattern")
+	}
+	if _, err := path.Match(strings.ReplaceAll(p, "**", "*"), ""); err != nil {
+		return nil, fmt.Errorf("invalid glob pattern %q: %v", pattern, err)
+	}
+	var b strings.Builder
+	b.WriteString("^")
+	if !strings.Contains(p, "/") {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(p); i++ {
+		switch c := p[i]; {
+		case strings.HasPrefix(p[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(p[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(p[i:], ']')
+			b.WriteString(p[i : i+end+1])
+			i += end
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// withSearchSections, withSearchInclude and withSearchExclude add the
+// filter arguments of xmlui_search.
+func withSearchSections(sectionKeys []string) mcp.ToolOption {
+	return mcp.WithArray("sections",
+		mcp.Items(map[string]any{"type": "string", "enum": sectionKeys}),
+		mcp.Description("Optional sections to search, e.g. [\"components\",\"source\"]. Roots that cannot yield these sections are skipped entirely."),
+	)
+}
+
+func withSearchInclude() mcp.ToolOption {
+	return mcp.WithArray("include",
+		mcp.Items(map[string]any{"type": "string"}),
+		mcp.Description("Optional repo-relative path globs a file must match, e.g. [\"*.tsx\"] or [\"xmlui/src/components/**\"]. '**' spans directories; a pattern without '/' matches the file name."),
+	)
+}
+
+func withSearchExclude() mcp.ToolOption {
+	return mcp.WithArray("exclude",
+		mcp.Items(map[string]any{"type": "string"}),
+		mcp.Description("Optional repo-relative path globs to skip, e.g. [\"blog/**\", \"*.scss\"]."),
+	)
+}
+
+// searchFilterArguments reads and validates sections/include/exclude. Each
+// accepts a JSON array of strings or a comma-separated string.
+func searchFilterArguments(req mcp.CallToolRequest, sectionKeys []string) (sections, include, exclude []string, err error) {
+	if sections, err = stringListArgument(req, "sections"); err != nil {
+		return nil, nil, nil, err
+	}
+	for _, s := range sections {
+		if !containsString(sectionKeys, s) {
+			return nil, nil, nil, fmt.Errorf("Invalid 'sections' value %q: use one of %s", s, strings.Join(sectionKeys, ", "))
+		}
+	}
+	if include, err = stringListArgument(req, "include"); err != nil {
+		return nil, nil, nil, err
+	}
+	if exclude, err = stringListArgument(req, "exclude"); err != nil {
+		return nil, nil, nil, err
+	}
+	if _, err = newSearchFilter(nil, include, exclude); err != nil {
+		return nil, nil, nil, fmt.Errorf("Invalid path filter: %v", err)
+	}
+	return sections, include, exclude, nil
+}
+
+func stringListArgument(req mcp.CallToolRequest, name string) ([]string, error) {
+	var values []string
+	switch raw := req.Params.Arguments[name].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		values = strings.Split(raw, ",")
+	case []interface{}:
+		for _, item := range raw {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("Invalid '%s' parameter: expected an array of strings", name)
+			}
+			values = append(values, s)
+		}
+	default:
+		return nil, fmt.Errorf("Invalid '%s' parameter: expected an array of strings", name)
+	}
+	var out []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
+
+func containsString(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
+
+// sharesString reports whether a and b have an element in common.
+func sharesString(a, b []string) bool {
+	for _, v := range a {
+		if containsString(b, v) {
+			return true
+		}
+	}
+	return false
+}
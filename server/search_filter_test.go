@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestGlobToRegexp(t *testing.T) {
+	cases := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"*.tsx", "xmlui/src/components/Button/Button.tsx", true},
+		{"*.tsx", "xmlui/src/components/Button/Button.md", false},
+		{"blog/**", "blog/2024/post.md", true},
+		{"blog/**", "docs/blog/post.md", false},
+		{"source/*", "source/Button.tsx", true},
+		{"source/*", "source/Button/Button.tsx", false},
+		{"**/Button/*.tsx", "source/Button/Button.tsx", true},
+		{"**/Button/*.tsx", "Button/Button.tsx", true},
+		{"Butto?.tsx", "source/Button.tsx", true},
+		{"[AB]utton.tsx", "source/Button.tsx", true},
+	}
+	for _, tc := range cases {
+		re, err := globToRegexp(tc.pattern)
+		if err != nil {
+			t.Fatalf("%q: %v", tc.pattern, err)
+		}
+		if got := re.MatchString(tc.path); got != tc.want {
+			t.Errorf("%q vs %q: got %v, want %v", tc.pattern, tc.path, got, tc.want)
+		}
+	}
+	if _, err := globToRegexp("[abc"); err == nil {
+		t.Error("malformed pattern compiled")
+	}
+}
+
+func TestExcludesRootOnlyWhenWholeRootCovered(t *testing.T) {
+	f, err := newSearchFilter(nil, nil, []string{"blog/**", "source/*.scss"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !f.excludesRoot("blog") {
+		t.Error("blog/** must drop the blog root")
+	}
+	if f.excludesRoot("source") {
+		t.Error("source/*.scss covers only some files; the root must stay")
+	}
+}
+
+func TestSearchSectionsAndGlobsPruneResults(t *testing.T) {
+	root := writeFormatFixture(t)
+	files := map[string]string{
+		"source/needle.tsx":  "export const uniqueNeedle = 1",
+		"source/needle.scss": ".uniqueNeedle {}",
+		"blog/needle.md":     "# Unique needle",
+	}
+	for rel, content := range files {
+		if err := os.WriteFile(filepath.Join(root, rel), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, handler := NewSearchTool(root, nil)
+
+	req := formatRequest("uniqueneedle", "json")
+	req.Params.Arguments["sections"] = []interface{}{"source"}
+	req.Params.Arguments["include"] = []interface{}{"*.tsx"}
+	summary := decodeSummary(t, handler, req)
+	var got []string
+	for section, items := range summary.Sections {
+		for _, item := range items {
+			got = append(got, section+":"+item.Path)
+		}
+	}
+	if len(got) == 0 {
+		t.Fatal("filtered search found nothing")
+	}
+	for _, g := range got {
+		if g != "source:source/needle.tsx" {
+			t.Fatalf("result escaped the filter: %v", got)
+		}
+	}
+
+	req = formatRequest("unique needle", "json")
+	req.Params.Arguments["exclude"] = "blog/**"
+	summary = decodeSummary(t, handler, req)
+	if len(summary.Sections["blog"]) != 0 || len(summary.Sections["components"]) == 0 {
+		t.Fatalf("exclude did not drop only the blog: %+v", summary.Sections)
+	}
+
+	req = formatRequest("unique needle", "json")
+	req.Params.Arguments["sections"] = []interface{}{"tutorials"}
+	result, err := handler(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.IsError {
+		t.Fatal("unknown section accepted")
+	}
+}
+
+func decodeSummary(t *testing.T, handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), req mcp.CallToolRequest) MediatorJSON {
+	t.Helper()
+	result, err := handler(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	texts := textItems(t, result)
+	if result.IsError {
+		t.Fatalf("tool error: %q", texts)
+	}
+	var summary MediatorJSON
+	if err := json.Unmarshal([]byte(texts[len(texts)-1]), &summary); err != nil {
+		t.Fatalf("JSON item does not decode: %v", err)
+	}
+	return summary
+}
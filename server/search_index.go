@@ -0,0 +1,431 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// searchIndexVersion is bumped whenever the on-disk layout or the line
+// scanning rules change; a persisted index with another version is rebuilt.
+const searchIndexVersion = 1
+
+// searchIndex is the per-root form of the corpus the mediator answers from:
+// every allowed file in WalkDir order with its scanned lines, plus a
+// trigram → file postings map that prunes the files a stage must scan.
+// Lines are read with the same bufio.Scanner rules the per-query walk used,
+// so matching, hit order and therefore ranking are unchanged.
+type searchIndex struct {
+	Version    int
+	Root       string
+	Extensions []string
+	Files      []indexedFile
+
+	postings   map[uint32][]int
+	words      []int  // per-file word counts, for length-normalized scorers
+	generation uint64 // distinct per built or loaded index; see searchCache
+
+	vocabOnce sync.Once
+	vocab     map[string]vocabWord // see vocabulary
+
+	deprecationsOnce   sync.Once
+	deprecationsByPath map[string][]docDeprecation // see deprecations
+
+	markupOnce sync.Once
+	markup     map[string]*markupDoc // see markupDocs
+
+	schemasOnce sync.Once
+	schemas     map[string]*ComponentSchema // see componentSchemas
+}
+
+// indexedFile is one file of a searchIndex. Size and ModTime detect changes
+// on refresh; Trigrams is the sorted set of lowercased line trigrams.
+type indexedFile struct {
+	Path     string
+	Name     string
+	Size     int64
+	ModTime  int64
+	Lines    []string
+	Trigrams []uint32
+}
+
+var (
+	searchIndexMu     sync.Mutex
+	searchIndexes     = map[string]*searchIndex{}
+	searchIndexDir    string
+	searchIndexCorpus string
+	searchIndexBuilds = map[string]*searchIndexBuild{}
+	searchIndexGen    atomic.Uint64
+)
+
+// searchIndexBuild is one key's in-flight load or build. It runs detached
+// from the search that started it, so a search deadline never discards a
+// half-built index; idx is set before done closes.
+type searchIndexBuild struct {
+	done chan struct{}
+	idx  *searchIndex
+}
+
+// SetSearchIndexDir sets the directory persisted search indexes live under
+// (one subdirectory per corpus tag). Empty keeps indexes in memory only.
+func SetSearchIndexDir(dir string) {
+	searchIndexMu.Lock()
+	defer searchIndexMu.Unlock()
+	searchIndexDir = dir
+}
+
+// SetSearchIndexCorpus names the downloaded corpus checkout. Its directory
+// is a released, versioned snapshot, so indexes of roots inside it are built
+// once per corpus tag and never re-statted. Every other root (example
+// projects the agent may be editing) is re-statted on each search and only
+// changed files are re-read.
+func SetSearchIndexCorpus(dir string) {
+	searchIndexMu.Lock()
+	defer searchIndexMu.Unlock()
+	searchIndexCorpus = dir
+}
+
+// ResetSearchIndexes drops every in-memory index, for testing.
+func ResetSearchIndexes() {
+	searchIndexMu.Lock()
+	defer searchIndexMu.Unlock()
+	searchIndexes = map[string]*searchIndex{}
+	searchIndexBuilds = map[string]*searchIndexBuild{}
+}
+
+// searchIndexFor returns the index for root restricted to exts, loading it
+// from disk or building it on first use. Roots outside the corpus snapshot
+// are refreshed on every call. homeDir names the corpus tag the persisted
+// copy is filed under. Concurrent callers share one build, which runs to
+// completion and is cached and persisted however long it takes; ctx bounds
+// only the wait. A caller whose ctx ends first gets the previous index, or
+// nil when there is none yet.
+func searchIndexFor(ctx context.Context, homeDir, root string, exts []string) *searchIndex {
+	normalized := normalizeExtensions(exts)
+	key := root + "|" + strings.Join(normalized, ",")
+
+	searchIndexMu.Lock()
+	current := searchIndexes[key]
+	immutable := searchIndexCorpus != "" && isWithinDir(searchIndexCorpus, root)
+	if current != nil && immutable {
+		searchIndexMu.Unlock()
+		return current
+	}
+	build := searchIndexBuilds[key]
+	if build == nil {
+		build = &searchIndexBuild{done: make(chan struct{})}
+		searchIndexBuilds[key] = build
+		diskPath := ""
+		if searchIndexDir != "" {
+			diskPath = searchIndexPath(searchIndexDir, homeDir, key)
+		}
+		go build.run(context.WithoutCancel(ctx), key, root, normalized, diskPath, immutable, current)
+	}
+	searchIndexMu.Unlock()
+
+	select {
+	case <-build.done:
+		return build.idx
+	case <-ctx.Done():
+		return current
+	}
+}
+
+// run loads or builds the index for key, publishes it, and releases the
+// callers waiting on b.
+func (b *searchIndexBuild) run(ctx context.Context, key, root string, exts []string, diskPath string, immutable bool, current *searchIndex) {
+	defer close(b.done)
+	if current == nil && diskPath != "" {
+		current = loadSearchIndex(diskPath, root, exts)
+		if current != nil && immutable {
+			b.idx = current
+		}
+	}
+	if b.idx == nil {
+		next, changed := buildSearchIndex(ctx, root, exts, current)
+		if changed && diskPath != "" {
+			saveSearchIndex(diskPath, next)
+		}
+		b.idx = next
+	}
+
+	searchIndexMu.Lock()
+	defer searchIndexMu.Unlock()
+	// A reset while building leaves b orphaned; its result is dropped.
+	if searchIndexBuilds[key] == b {
+		searchIndexes[key] = b.idx
+		delete(searchIndexBuilds, key)
+	}
+}
+
+// searchIndexSet is one search's indexes, by root. Each root is resolved
+// (and, outside the corpus, refreshed) at most once, so the query cache's
+// lookup and the stages after it share one walk.
+type searchIndexSet struct {
+	homeDir string
+	exts    []string
+
+	mu     sync.Mutex
+	byRoot map[string]*searchIndex
+}
+
+func newSearchIndexSet(homeDir string, exts []string) *searchIndexSet {
+	return &searchIndexSet{homeDir: homeDir, exts: exts, byRoot: map[string]*searchIndex{}}
+}
+
+// resolve returns the indexes of roots in order, resolving the ones not yet
+// resolved concurrently. A root whose index was not ready before ctx ended
+// is nil, and is tried again by a later call.
+func (s *searchIndexSet) resolve(ctx context.Context, roots []string) []*searchIndex {
+	out := make([]*searchIndex, len(roots))
+	forEachRoot(ctx, len(roots), func(i int) {
+		s.mu.Lock()
+		idx := s.byRoot[roots[i]]
+		s.mu.Unlock()
+		if idx == nil {
+			idx = searchIndexFor(ctx, s.homeDir, roots[i], s.exts)
+		}
+		if idx != nil {
+			s.mu.Lock()
+			s.byRoot[roots[i]] = idx
+			s.mu.Unlock()
+		}
+		out[i] = idx
+	})
+	return out
+}
+
+// buildSearchIndex walks root and returns its index, reusing every file of
+// previous whose size and mtime are unchanged. changed reports whether the
+// result differs from previous (and so should be persisted).
+func buildSearchIndex(ctx context.Context, root string, exts []string, previous *searchIndex) (*searchIndex, bool) {
+	reuse := map[string]*indexedFile{}
+	if previous != nil {
+		for i := range previous.Files {
+			reuse[previous.Files[i].Path] = &previous.Files[i]
+		}
+	}
+
+	idx := &searchIndex{Version: searchIndexVersion, Root: root, Extensions: exts}
+	changed := previous == nil
+	_ = filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if d.Name() == "node_modules" || d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !hasAllowedExt(d.Name(), exts) {
+			return nil
+		}
+		var size, modTime int64
+		if info, err := d.Info(); err == nil {
+			size, modTime = info.Size(), info.ModTime().UnixNano()
+		}
+		if old, ok := reuse[path]; ok && old.Size == size && old.ModTime == modTime {
+			idx.Files = append(idx.Files, *old)
+			return nil
+		}
+		changed = true
+		idx.Files = append(idx.Files, readIndexedFile(path, d.Name(), size, modTime))
+		return nil
+	})
+	if previous != nil && len(previous.Files) != len(idx.Files) {
+		changed = true
+	}
+	if !changed {
+		return previous, false
+	}
+	idx.buildPostings()
+	return idx, true
+}
+
+// readIndexedFile scans one file exactly as the per-query walk did: a
+// default bufio.Scanner, so an over-long line ends the file there too. An
+// unreadable file is kept with no lines so filename matches still fire.
+func readIndexedFile(path, name string, size, modTime int64) indexedFile {
+	file := indexedFile{Path: path, Name: name, Size: size, ModTime: modTime}
+	f, err := os.Open(path)
+	if err != nil {
+		return file
+	}
+	defer f.Close()
+
+	seen := map[uint32]bool{}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		file.Lines = append(file.Lines, line)
+		for _, tg := range trigramsOf(strings.ToLower(line)) {
+			seen[tg] = true
+		}
+	}
+	file.Trigrams = make([]uint32, 0, len(seen))
+	for tg := range seen {
+		file.Trigrams = append(file.Trigrams, tg)
+	}
+	sort.Slice(file.Trigrams, func(i, j int) bool { return file.Trigrams[i] < file.Trigrams[j] })
+	return file
+}
+
+func (idx *searchIndex) buildPostings() {
+	idx.generation = searchIndexGen.Add(1)
+	idx.postings = make(map[uint32][]int)
+	idx.words = make([]int, len(idx.Files))
+	for i, file := range idx.Files {
+		for _, tg := range file.Trigrams {
+			idx.postings[tg] = append(idx.postings[tg], i)
+		}
+		for _, line := range file.Lines {
+			idx.words[i] += len(strings.Fields(line))
+		}
+	}
+}
+
+// candidateFiles marks the files that may contain at least minNeedles of
+// needles as lowercase substrings of a single line. It is a superset test:
+// a file is pruned only when some needle's trigrams are provably absent.
+// Needles shorter than a trigram cannot prune and always count as present.
+func (idx *searchIndex) candidateFiles(needles []string, minNeedles int) []bool {
+	candidate := make([]bool, len(idx.Files))
+	if minNeedles <= 0 {
+		for i := range candidate {
+			candidate[i] = true
+		}
+		return candidate
+	}
+	present := make([]int, len(idx.Files))
+	for _, needle := range needles {
+		tgs := dedupeTrigrams(trigramsOf(needle))
+		if len(tgs) == 0 {
+			for i := range present {
+				present[i]++
+			}
+			continue
+		}
+		counts := make(map[int]int)
+		for _, tg := range tgs {
+			for _, fileID := range idx.postings[tg] {
+				counts[fileID]++
+			}
+		}
+		for fileID, n := range counts {
+			if n == len(tgs) {
+				present[fileID]++
+			}
+		}
+	}
+	for i, n := range present {
+		candidate[i] = n >= minNeedles
+	}
+	return candidate
+}
+
+// trigramsOf packs every 3-byte window of s into a uint32.
+func trigramsOf(s string) []uint32 {
+	if len(s) < 3 {
+		return nil
+	}
+	out := make([]uint32, 0, len(s)-2)
+	for i := 0; i+3 <= len(s); i++ {
+		out = append(out, uint32(s[i])<<16|uint32(s[i+1])<<8|uint32(s[i+2]))
+	}
+	return out
+}
+
+func dedupeTrigrams(tgs []uint32) []uint32 {
+	seen := make(map[uint32]bool, len(tgs))
+	out := make([]uint32, 0, len(tgs))
+	for _, tg := range tgs {
+		if !seen[tg] {
+			seen[tg] = true
+			out = append(out, tg)
+		}
+	}
+	return out
+}
+
+// isWithinDir reports whether path is dir or lies beneath it.
+func isWithinDir(dir, path string) bool {
+	rel, err := filepath.Rel(filepath.Clean(dir), filepath.Clean(path))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// normalizeExtensions lowercases and sorts exts so tools that list the same
+// extensions in a different order share one index.
+func normalizeExtensions(exts []string) []string {
+	out := make([]string, 0, len(exts))
+	for _, e := range exts {
+		out = append(out, strings.ToLower(e))
+	}
+	sort.Strings(out)
+	return dedupeStrings(out)
+}
+
+// searchIndexPath files an index under its corpus tag, keyed by a hash of
+// root and extensions so the file name stays short and portable.
+func searchIndexPath(dir, homeDir, key string) string {
+	tag := corpusVersionForDir(homeDir)
+	if tag == "" {
+		tag = "default"
+	}
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(dir, tag, hex.EncodeToString(sum[:8])+".gob")
+}
+
+func loadSearchIndex(path, root string, exts []string) *searchIndex {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var idx searchIndex
+	if err := gob.NewDecoder(f).Decode(&idx); err != nil {
+		WriteDebugLog("Failed to load search index %s: %v\n", path, err)
+		return nil
+	}
+	if idx.Version != searchIndexVersion || idx.Root != root || strings.Join(idx.Extensions, ",") != strings.Join(exts, ",") {
+		return nil
+	}
+	idx.buildPostings()
+	return &idx
+}
+
+// saveSearchIndex writes atomically (temp file + rename) so a crash never
+// leaves a truncated index behind. Failures only cost the next startup.
+func saveSearchIndex(path string, idx *searchIndex) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		WriteDebugLog("Failed to create search index dir: %v\n", err)
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".index-*")
+	if err != nil {
+		WriteDebugLog("Failed to create search index temp file: %v\n", err)
+		return
+	}
+	if err := gob.NewEncoder(tmp).Encode(idx); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		WriteDebugLog("Failed to encode search index: %v\n", err)
+		return
+	}
+	tmp.Close()
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		WriteDebugLog("Failed to save search index: %v\n", err)
+	}
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func useTestSearchIndexes(t *testing.T, dir, corpus string) {
+	t.Helper()
+	ResetSearchIndexes()
+	SetSearchIndexDir(dir)
+	SetSearchIndexCorpus(corpus)
+	t.Cleanup(func() {
+		ResetSearchIndexes()
+		SetSearchIndexDir("")
+		SetSearchIndexCorpus("")
+	})
+}
+
+// Pruning is a superset test: every file with a line containing the needles
+// stays a candidate, and only files provably missing a needle drop out.
+func TestSearchIndexCandidatesNeverDropMatches(t *testing.T) {
+	useTestSearchIndexes(t, "", "")
+	root := t.TempDir()
+	writeHowtoFixture(t, root, "a.md", "# Alpha\nUse the DataSource here.\n")
+	writeHowtoFixture(t, root, "b.md", "# Beta\nNothing relevant.\n")
+	writeHowtoFixture(t, root, "c.md", "# Gamma\ndatasource\nand a button\n")
+
+	idx := searchIndexFor(context.Background(), root, root, []string{".md"})
+	if len(idx.Files) != 3 {
+		t.Fatalf("expected 3 indexed files, got %d", len(idx.Files))
+	}
+
+	got := idx.candidateFiles([]string{"datasource"}, 1)
+	if !reflect.DeepEqual(got, []bool{true, false, true}) {
+		t.Fatalf("unexpected candidates for single needle: %v", got)
+	}
+	// c.md holds both needles, on different lines: still a candidate (the
+	// line scan decides), while a.md lacks "button" entirely.
+	got = idx.candidateFiles([]string{"datasource", "button"}, 2)
+	if !reflect.DeepEqual(got, []bool{false, false, true}) {
+		t.Fatalf("unexpected candidates for two needles: %v", got)
+	}
+	// Needles shorter than a trigram cannot prune.
+	got = idx.candidateFiles([]string{"zz"}, 1)
+	if !reflect.DeepEqual(got, []bool{true, true, true}) {
+		t.Fatalf("short needle must not prune: %v", got)
+	}
+}
+
+func TestSearchIndexPersistsAndReloads(t *testing.T) {
+	cacheDir := t.TempDir()
+	corpus := t.TempDir()
+	root := filepath.Join(corpus, "howto")
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	useTestSearchIndexes(t, cacheDir, corpus)
+	writeHowtoFixture(t, root, "a.md", "# Alpha\nBody.\n")
+
+	first := searchIndexFor(context.Background(), corpus, root, []string{".md"})
+	matches, _ := filepath.Glob(filepath.Join(cacheDir, filepath.Base(corpus), "*.gob"))
+	if len(matches) != 1 {
+		t.Fatalf("expected one persisted index under the corpus tag, got %v", matches)
+	}
+
+	// A fresh process loads the persisted copy; corpus roots are snapshots
+	// and are not re-read, so a later edit is invisible by design.
+	ResetSearchIndexes()
+	writeHowtoFixture(t, root, "b.md", "# Beta\nBody.\n")
+	loaded := searchIndexFor(context.Background(), corpus, root, []string{".md"})
+	if len(loaded.Files) != len(first.Files) || loaded.Files[0].Lines[0] != "# Alpha" {
+		t.Fatalf("expected the persisted index, got %+v", loaded.Files)
+	}
+}
+
+// Example roots are live projects: an edit must show up on the next search.
+func TestSearchIndexRefreshesMutableRoots(t *testing.T) {
+	useTestSearchIndexes(t, t.TempDir(), "")
+	root := t.TempDir()
+	writeHowtoFixture(t, root, "a.md", "# Alpha\nold text\n")
+
+	idx := searchIndexFor(context.Background(), root, root, []string{".md"})
+	if idx.Files[0].Lines[1] != "old text" {
+		t.Fatalf("unexpected initial lines: %v", idx.Files[0].Lines)
+	}
+
+	writeHowtoFixture(t, root, "a.md", "# Alpha\nnew text, longer\n")
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(filepath.Join(root, "a.md"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	writeHowtoFixture(t, root, "b.md", "# Beta\n")
+
+	idx = searchIndexFor(context.Background(), root, root, []string{".md"})
+	if len(idx.Files) != 2 || idx.Files[0].Lines[1] != "new text, longer" {
+		t.Fatalf("expected refreshed index, got %+v", idx.Files)
+	}
+}
+
+// A search whose deadline ends before a cold build finishes stops waiting,
+// but the build runs on and is cached and persisted for the next search.
+func TestSearchIndexBuildOutlivesCanceledSearch(t *testing.T) {
+	cacheDir := t.TempDir()
+	corpus := t.TempDir()
+	root := filepath.Join(corpus, "howto")
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	useTestSearchIndexes(t, cacheDir, corpus)
+	writeHowtoFixture(t, root, "a.md", "# Alpha\nBody.\n")
+	writeHowtoFixture(t, root, "b.md", "# Beta\nBody.\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if idx := searchIndexFor(ctx, corpus, root, []string{".md"}); idx != nil && len(idx.Files) != 2 {
+		t.Fatalf("canceled search got a partial index: %+v", idx.Files)
+	}
+
+	idx := searchIndexFor(context.Background(), corpus, root, []string{".md"})
+	if idx == nil || len(idx.Files) != 2 {
+		t.Fatalf("expected the completed build, got %+v", idx)
+	}
+	matches, _ := filepath.Glob(filepath.Join(cacheDir, filepath.Base(corpus), "*.gob"))
+	if len(matches) != 1 {
+		t.Fatalf("expected the build to be persisted, got %v", matches)
+	}
+}
@@ -0,0 +1,175 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Output formats accepted by the search tools' "format" argument.
+const (
+	searchFormatText = "text"
+	searchFormatJSON = "json"
+	searchFormatBoth = "both"
+)
+
+// MediatorJSONSchemaURI is the resource URI the MediatorJSON output schema
+// is published under. The pinned mcp-go has no Tool.outputSchema field, so
+// clients discover the schema here (and from the format argument's
+// description) until the dependency can carry it on the tool itself.
+const MediatorJSONSchemaURI = "xmlui://schemas/mediator-json"
+
+// withSearchFormat adds the shared "format" argument to a search tool.
+func withSearchFormat() mcp.ToolOption {
+	return mcp.WithString("format",
+		mcp.Enum(searchFormatText, searchFormatJSON, searchFormatBoth),
+		mcp.Description("Optional output format. 'text' (default) returns the human-readable block; "+
+			"'json' returns only the MediatorJSON summary as a JSON text item; 'both' returns the "+
+			"human block followed by the JSON item. The JSON schema is published at "+MediatorJSONSchemaURI+"."),
+	)
+}
+
+// searchFormatArgument reads the optional "format" argument, defaulting to text.
+func searchFormatArgument(req mcp.CallToolRequest) (string, error) {
+	raw, _ := req.Params.Arguments["format"].(string)
+	format := strings.ToLower(strings.TrimSpace(raw))
+	switch format {
+	case "":
+		return searchFormatText, nil
+	case searchFormatText, searchFormatJSON, searchFormatBoth:
+		return format, nil
+	default:
+		return "", fmt.Errorf("Invalid 'format' parameter %q: use 'text', 'json', or 'both'", raw)
+	}
+}
+
+// withSearchExplain adds the shared "explain" argument.
+func withSearchExplain() mcp.ToolOption {
+	return mcp.WithBoolean("explain",
+		mcp.Description("Optional, default false. Adds each ranked file's score breakdown (term coverage, section weight, filename bonus and the term that fired it, topic bonus source, match density, deprecation multiplier) under the file, and in full, with the filename-bonus eligibility of each term, under Diagnostics.explain of the JSON summary."),
+	)
+}
+
+// searchBoolArgument reads an optional boolean argument.
+func searchBoolArgument(req mcp.CallToolRequest, name string, def bool) (bool, error) {
+	raw, ok := req.Params.Arguments[name]
+	if !ok || raw == nil {
+		return def, nil
+	}
+	v, ok := raw.(bool)
+	if !ok {
+		return false, fmt.Errorf("Invalid '%s' parameter: must be a boolean", name)
+	}
+	return v, nil
+}
+
+// searchToolResult renders a mediated search in the requested format. The
+// JSON is always its own content item, never appended to prose, so a client
+// can decode it without scraping.
+func searchToolResult(format string, human string, summary MediatorJSON) *mcp.CallToolResult {
+	if format == searchFormatText {
+		return mcp.NewToolResultText(human)
+	}
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to encode search summary: %v", err))
+	}
+	if format == searchFormatJSON {
+		return mcp.NewToolResultText(string(data))
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.NewTextContent(human),
+			mcp.NewTextContent(string(data)),
+		},
+	}
+}
+
+// isJSONText reports whether a text content item is a JSON document, so the
+// update notice and corpus stamp ride beside it instead of corrupting it.
+func isJSONText(text string) bool {
+	trimmed := strings.TrimSpace(text)
+	return strings.HasPrefix(trimmed, "{") && json.Valid([]byte(trimmed))
+}
+
+// MediatorJSONSchema returns the JSON Schema of MediatorJSON, derived from
+// its struct tags so the published schema cannot drift from the encoder.
+func MediatorJSONSchema() map[string]any {
+	schema := jsonSchemaFor(reflect.TypeOf(MediatorJSON{}))
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = "MediatorJSON"
+	return schema
+}
+
+// NewMediatorSchemaResource returns the MCP resource and handler publishing
+// MediatorJSONSchema.
+func NewMediatorSchemaResource() (mcp.Resource, func(context.Context, mcp.ReadResourceRequest) ([]mcp.ResourceContents, error)) {
+	resource := mcp.NewResource(MediatorJSONSchemaURI, "MediatorJSON schema",
+		mcp.WithResourceDescription("JSON Schema for the structured summary returned by xmlui_search, xmlui_search_howto and xmlui_examples with format 'json' or 'both'."),
+		mcp.WithMIMEType("application/schema+json"),
+	)
+	handler := func(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		data, err := json.MarshalIndent(MediatorJSONSchema(), "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{URI: MediatorJSONSchemaURI, MIMEType: "application/schema+json", Text: string(data)},
+		}, nil
+	}
+	return resource, handler
+}
+
+// jsonSchemaFor maps a Go type to JSON Schema following encoding/json's
+// rules: tagged field names, "-" skipped, omitempty fields not required.
+func jsonSchemaFor(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": jsonSchemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": jsonSchemaFor(t.Elem())}
+	case reflect.Struct:
+		properties := map[string]any{}
+		required := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			properties[name] = jsonSchemaFor(field.Type)
+			if !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+		schema := map[string]any{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	default:
+		return map[string]any{}
+	}
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func formatRequest(query, format string) mcp.CallToolRequest {
+	req := searchRequest(query)
+	req.Params.Arguments["format"] = format
+	return req
+}
+
+func writeFormatFixture(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	manifest := `{"componentDocs":"components","componentSource":"source","extensionDocs":"components","extensionSource":"packages","pages":"pages","howto":"howto","blog":"blog"}`
+	if err := os.WriteFile(filepath.Join(root, "mcp-paths.json"), []byte(manifest), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{"components", "source", "packages", "pages", "howto", "blog"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, "components", "needle.mdx"), []byte("# Unique needle"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ResetRepoPaths()
+	return root
+}
+
+func textItems(t *testing.T, result *mcp.CallToolResult) []string {
+	t.Helper()
+	var texts []string
+	for _, content := range result.Content {
+		tc, ok := content.(mcp.TextContent)
+		if !ok {
+			t.Fatalf("unexpected content type %T", content)
+		}
+		texts = append(texts, tc.Text)
+	}
+	return texts
+}
+
+// The JSON item stays decodable even when the update notice and corpus
+// stamp are attached to the response.
+func TestSearchFormatJSONSurvivesNoticeAndStamp(t *testing.T) {
+	useTestAnalytics(t)
+	t.Cleanup(func() {
+		SetUpdateNotice("")
+		SetCorpusStamp("")
+	})
+	SetUpdateNotice("UPDATE-NOTICE")
+	stamp := "[corpus: xmlui@0.0.1, 2 how-tos]"
+	SetCorpusStamp(stamp)
+
+	root := writeFormatFixture(t)
+	_, handler := NewSearchTool(root, nil)
+	result, err := WithSearchAnalytics("xmlui_search", handler)(context.Background(), formatRequest("unique needle", "json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	texts := textItems(t, result)
+	if len(texts) != 3 || texts[0] != "UPDATE-NOTICE" || texts[2] != stamp {
+		t.Fatalf("want notice, JSON, stamp items; got %q", texts)
+	}
+	var summary MediatorJSON
+	if err := json.Unmarshal([]byte(texts[1]), &summary); err != nil {
+		t.Fatalf("JSON item does not decode: %v\n%s", err, texts[1])
+	}
+	if len(summary.Sections["components"]) == 0 {
+		t.Fatalf("decoded summary lost its results: %+v", summary)
+	}
+}
+
+func TestSearchFormatBothReturnsHumanThenJSON(t *testing.T) {
+	root := writeFormatFixture(t)
+	_, handler := NewSearchTool(root, nil)
+	result, err := handler(context.Background(), formatRequest("unique needle", "both"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	texts := textItems(t, result)
+	if len(texts) != 2 || isJSONText(texts[0]) || !isJSONText(texts[1]) {
+		t.Fatalf("want human block then JSON; got %q", texts)
+	}
+
+	textResult, err := handler(context.Background(), searchRequest("unique needle"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := textItems(t, textResult); len(got) != 1 || got[0] != texts[0] {
+		t.Fatalf("default format must be the human block alone; got %q", got)
+	}
+}
+
+func TestSearchFormatRejectsUnknownValue(t *testing.T) {
+	root := writeFormatFixture(t)
+	_, handler := NewSearchTool(root, nil)
+	result, err := handler(context.Background(), formatRequest("unique needle", "xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.IsError || !strings.Contains(textItems(t, result)[0], "format") {
+		t.Fatalf("want a format error; got %+v", result)
+	}
+}
+
+func TestMediatorJSONSchemaMatchesEncoding(t *testing.T) {
+	schema := MediatorJSONSchema()
+	properties := schema["properties"].(map[string]any)
+	data, err := json.Marshal(MediatorJSON{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var encoded map[string]any
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		t.Fatal(err)
+	}
+	for key := range encoded {
+		if _, ok := properties[key]; !ok {
+			t.Errorf("encoded key %q missing from schema", key)
+		}
+	}
+}
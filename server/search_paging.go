@@ -0,0 +1,101 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// searchCursor is the decoded form of MediatorJSON.NextCursor: the file
+// offset of the next page, bound to the tool, query and result-set options
+// that issued it so a cursor replayed against another search fails loudly
+// instead of skipping an arbitrary number of unrelated results.
+type searchCursor struct {
+	Offset int    `json:"o"`
+	Query  string `json:"q"`
+}
+
+// withSearchOffset and withSearchCursor add the shared paging arguments to
+// a search tool.
+func withSearchOffset() mcp.ToolOption {
+	return mcp.WithNumber("offset", mcp.Description("Optional number of ranked files to skip (default 0). Pages are deterministic for an unchanged corpus."))
+}
+
+func withSearchCursor() mcp.ToolOption {
+	return mcp.WithString("cursor", mcp.Description("Optional next_cursor from a previous call with the same query and filters; takes precedence over offset."))
+}
+
+// searchPagingArgument resolves the optional cursor/offset arguments into a
+// file offset for cfg.Offset. cfg must already carry the search's options.
+func searchPagingArgument(req mcp.CallToolRequest, cfg MediatorConfig, query string) (int, error) {
+	if raw, ok := req.Params.Arguments["cursor"].(string); ok && strings.TrimSpace(raw) != "" {
+		return decodeSearchCursor(strings.TrimSpace(raw), cfg, query)
+	}
+	raw, ok := req.Params.Arguments["offset"]
+	if !ok || raw == nil {
+		return 0, nil
+	}
+	offset, ok := raw.(float64)
+	if !ok || offset < 0 || offset != float64(int(offset)) {
+		return 0, fmt.Errorf("Invalid 'offset' parameter: must be a non-negative integer")
+	}
+	return int(offset), nil
+}
+
+func encodeSearchCursor(cfg MediatorConfig, query string, offset int) string {
+	data, _ := json.Marshal(searchCursor{Offset: offset, Query: searchCursorKey(cfg, query)})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeSearchCursor(cursor string, cfg MediatorConfig, query string) (int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	var decoded searchCursor
+	if err == nil {
+		err = json.Unmarshal(data, &decoded)
+	}
+	if err != nil || decoded.Offset < 0 {
+		return 0, fmt.Errorf("Invalid 'cursor' parameter: pass next_cursor unchanged from a previous result")
+	}
+	if decoded.Query != searchCursorKey(cfg, query) {
+		return 0, fmt.Errorf("Invalid 'cursor' parameter: it was issued for a different query, tool or filters")
+	}
+	return decoded.Offset, nil
+}
+
+// searchCursorKey fingerprints the tool, the query and the options that
+// change the ranked result set: sections, include/exclude, granularity,
+// fuzzy, mode and the scorer. The query is keyed as typed, as the search
+// cache keys it: "OR" is an operator and "or" a stopword, so the two page
+// through different results. Filter order does not invalidate a cursor.
+func searchCursorKey(cfg MediatorConfig, query string) string {
+	granularity := cfg.Granularity
+	if granularity == "" {
+		granularity = SearchGranularityFile
+	}
+	fields := []string{
+		cfg.ToolName,
+		strings.TrimSpace(query),
+		sortedJoin(cfg.Sections),
+		sortedJoin(cfg.Include),
+		sortedJoin(cfg.Exclude),
+		granularity,
+		strconv.FormatBool(cfg.FuzzyTokens),
+		cfg.Mode,
+		scorerName(cfg.Scorer),
+	}
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\x00")))
+	return hex.EncodeToString(sum[:6])
+}
+
+func sortedJoin(values []string) string {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, "\x01")
+}
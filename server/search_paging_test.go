@@ -0,0 +1,141 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func pagedPaths(summary MediatorJSON) []string {
+	var paths []string
+	seen := map[string]bool{}
+	for _, item := range summary.Sections["howtos"] {
+		if !seen[item.Path] {
+			seen[item.Path] = true
+			paths = append(paths, item.Path)
+		}
+	}
+	return paths
+}
+
+// Equal-score files page in path order: consecutive cursors partition the
+// ranking with no file repeated or skipped.
+func TestCursorPagesPartitionRanking(t *testing.T) {
+	resetTopicIndexForTest(t)
+	root := t.TempDir()
+	howtoDir := filepath.Join(root, "howto")
+	if err := os.MkdirAll(howtoDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 7; i++ {
+		writeHowtoFixture(t, howtoDir, fmt.Sprintf("page-%02d.md", i), "# Notes\nThe tooltip placement option.\n")
+	}
+
+	cfg := howtoMediatorConfig(howtoDir)
+	cfg.ToolName = "xmlui_search_howto"
+	cfg.MaxFileResults = 3
+	query := "tooltip placement"
+
+	var all []string
+	var confidence string
+	for page := 0; page < 5; page++ {
+		human, summary, err := ExecuteMediatedSearch(root, cfg, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page == 0 {
+			confidence = summary.Confidence
+		} else if summary.Confidence != confidence {
+			t.Fatalf("confidence drifted across pages: %q then %q", confidence, summary.Confidence)
+		}
+		all = append(all, pagedPaths(summary)...)
+		if summary.NextCursor == "" {
+			break
+		}
+		if !strings.Contains(human, summary.NextCursor) {
+			t.Fatalf("human block does not offer the next cursor:\n%s", human)
+		}
+		offset, err := decodeSearchCursor(summary.NextCursor, cfg, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg.Offset = offset
+	}
+
+	if len(all) != 7 {
+		t.Fatalf("paged through %d files, want 7: %v", len(all), all)
+	}
+	for i := 1; i < len(all); i++ {
+		if all[i-1] >= all[i] {
+			t.Fatalf("pages are not in path order or repeat a file: %v", all)
+		}
+	}
+}
+
+func TestCursorRejectsOtherQuery(t *testing.T) {
+	cfg := MediatorConfig{ToolName: "xmlui_search", Sections: []string{"components", "howtos"}, Include: []string{"docs/**"}}
+	cursor := encodeSearchCursor(cfg, "tooltip placement", 15)
+	if offset, err := decodeSearchCursor(cursor, cfg, "tooltip placement "); err != nil || offset != 15 {
+		t.Fatalf("same query with trailing space: offset=%d err=%v", offset, err)
+	}
+	reordered := cfg
+	reordered.Sections = []string{"howtos", "components"}
+	if _, err := decodeSearchCursor(cursor, reordered, "tooltip placement"); err != nil {
+		t.Fatalf("same sections in other order: %v", err)
+	}
+	if _, err := decodeSearchCursor(cursor, cfg, "modal dialog"); err == nil {
+		t.Fatal("cursor accepted for a different query")
+	}
+	// "OR" is an operator and "or" a stopword: same letters, other results.
+	orCursor := encodeSearchCursor(cfg, "tooltip OR popover", 15)
+	if _, err := decodeSearchCursor(orCursor, cfg, "tooltip or popover"); err == nil {
+		t.Fatal("cursor for an OR query accepted for its lowercase form")
+	}
+	other := cfg
+	other.ToolName = "xmlui_examples"
+	if _, err := decodeSearchCursor(cursor, other, "tooltip placement"); err == nil {
+		t.Fatal("cursor accepted for a different tool")
+	}
+	for name, change := range map[string]func(*MediatorConfig){
+		"sections":    func(c *MediatorConfig) { c.Sections = []string{"components"} },
+		"include":     func(c *MediatorConfig) { c.Include = nil },
+		"exclude":     func(c *MediatorConfig) { c.Exclude = []string{"**/*.tsx"} },
+		"granularity": func(c *MediatorConfig) { c.Granularity = SearchGranularitySection },
+		"fuzzy":       func(c *MediatorConfig) { c.FuzzyTokens = true },
+		"scorer":      func(c *MediatorConfig) { c.Scorer = BM25Scorer{} },
+	} {
+		changed := cfg
+		change(&changed)
+		if _, err := decodeSearchCursor(cursor, changed, "tooltip placement"); err == nil {
+			t.Fatalf("cursor accepted with different %s", name)
+		}
+	}
+	if _, err := decodeSearchCursor("not-a-cursor", cfg, "tooltip placement"); err == nil {
+		t.Fatal("garbage cursor accepted")
+	}
+}
+
+func TestOffsetPastLastPageReportsTotal(t *testing.T) {
+	resetTopicIndexForTest(t)
+	root := t.TempDir()
+	howtoDir := filepath.Join(root, "howto")
+	if err := os.MkdirAll(howtoDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeHowtoFixture(t, howtoDir, "only.md", "# Tooltip placement\n")
+
+	cfg := howtoMediatorConfig(howtoDir)
+	cfg.Offset = 5
+	human, summary, err := ExecuteMediatedSearch(root, cfg, "tooltip placement")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pagedPaths(summary)) != 0 || summary.NextCursor != "" {
+		t.Fatalf("want an empty final page, got %+v", summary.Sections)
+	}
+	if !strings.Contains(human, "past the last of 1 matching files") {
+		t.Fatalf("human block does not explain the empty page:\n%s", human)
+	}
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// searchWorkers bounds how many roots a search scans at once. Roots are
+// few and uneven (source dwarfs blog), so a small pool keeps the largest
+// root from serializing the rest without oversubscribing the host.
+const searchWorkers = 4
+
+// DefaultSearchTimeout is how long one search may run before it returns
+// what it has found so far, marked truncated.
+const DefaultSearchTimeout = 20 * time.Second
+
+var (
+	searchTimeoutMu sync.RWMutex
+	searchTimeout   = DefaultSearchTimeout
+)
+
+// SetSearchTimeout sets the time budget of each search tool call. Zero or
+// less disables the budget; the caller's context still applies.
+func SetSearchTimeout(d time.Duration) {
+	searchTimeoutMu.Lock()
+	defer searchTimeoutMu.Unlock()
+	searchTimeout = d
+}
+
+// SearchTimeout returns the budget set by SetSearchTimeout.
+func SearchTimeout() time.Duration {
+	searchTimeoutMu.RLock()
+	defer searchTimeoutMu.RUnlock()
+	return searchTimeout
+}
+
+// forEachRoot runs fn(i) for i in [0, n) on at most searchWorkers
+// goroutines and waits for all of them. Once ctx is done, roots not yet
+// started are skipped; fn must check ctx itself to stop a root midway.
+// Callers write results into per-index slots and merge them in order
+// afterwards, which keeps results independent of scheduling.
+func forEachRoot(ctx context.Context, n int, fn func(i int)) {
+	if n == 1 {
+		if ctx.Err() == nil {
+			fn(0)
+		}
+		return
+	}
+	sem := make(chan struct{}, searchWorkers)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		if ctx.Err() != nil {
+			break
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// scanEvent is one hit a root scan finds in a file, replayed against the
+// shared accumulators in the order the scan made it.
+type scanEvent struct {
+	line int // 0: the filename matched
+	text string
+}
+
+// fileScan is the ordered events of one scanned file.
+type fileScan struct {
+	rel, path string
+	events    []scanEvent
+}
+
+// rootScan is what one root contributed to a stage. complete is false
+// when the scan stopped early because ctx was done.
+type rootScan struct {
+	files    []fileScan
+	complete bool
+}
+
+// searchTruncation reports why ctx ended a search early, for Diagnostics.
+func searchTruncation(ctx context.Context) string {
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		return "deadline"
+	case context.Canceled:
+		return "canceled"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestForEachRootBoundsWorkers(t *testing.T) {
+	var running, peak int32
+	ran := make([]bool, 10)
+	forEachRoot(context.Background(), len(ran), func(i int) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		ran[i] = true
+		atomic.AddInt32(&running, -1)
+	})
+	for i, ok := range ran {
+		if !ok {
+			t.Fatalf("root %d never ran", i)
+		}
+	}
+	if peak > searchWorkers {
+		t.Fatalf("peak concurrency %d exceeds %d workers", peak, searchWorkers)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	forEachRoot(ctx, 3, func(i int) { t.Fatalf("root %d ran after cancel", i) })
+}
+
+func TestCanceledSearchReportsTruncation(t *testing.T) {
+	resetTopicIndexForTest(t)
+	root := t.TempDir()
+	howtoDir := filepath.Join(root, "howto")
+	if err := os.MkdirAll(howtoDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeHowtoFixture(t, howtoDir, "modal.md", "# Open a modal dialog\nUse ModalDialog.\n")
+	cfg := howtoMediatorConfig(howtoDir)
+
+	_, summary, err := ExecuteMediatedSearch(root, cfg, "modal dialog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Diagnostics["truncated"] != false {
+		t.Fatalf("complete search marked truncated: %v", summary.Diagnostics)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	human, summary, err := ExecuteMediatedSearchContext(ctx, root, cfg, "modal dialog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Diagnostics["truncated"] != true || summary.Diagnostics["truncated_reason"] != "canceled" {
+		t.Fatalf("diagnostics = %v, want truncated by cancel", summary.Diagnostics)
+	}
+	if !strings.Contains(strings.ToLower(human), "truncated") {
+		t.Fatalf("human block does not say the search was cut short:\n%s", human)
+	}
+}
@@ -0,0 +1,180 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Result granularities selectable through MediatorConfig.Granularity and the
+// "granularity" tool argument.
+const (
+	SearchGranularityFile    = "file"
+	SearchGranularitySection = "section"
+)
+
+// withSearchGranularity adds the shared "granularity" argument.
+func withSearchGranularity() mcp.ToolOption {
+	return mcp.WithString("granularity",
+		mcp.Enum(SearchGranularityFile, SearchGranularitySection),
+		mcp.Description("Optional result unit. 'file' (default) ranks whole files; 'section' ranks each H2/H3-bounded section of a Markdown page on its own hits, "+
+			"with its heading, anchor URL and line range, so a long guide with scattered matches does not outrank the one section that answers."),
+	)
+}
+
+// searchGranularityArgument reads the optional "granularity" argument.
+func searchGranularityArgument(req mcp.CallToolRequest) (string, error) {
+	raw, ok := req.Params.Arguments["granularity"]
+	if !ok || raw == nil {
+		return SearchGranularityFile, nil
+	}
+	switch g, _ := raw.(string); g {
+	case "", SearchGranularityFile:
+		return SearchGranularityFile, nil
+	case SearchGranularitySection:
+		return SearchGranularitySection, nil
+	default:
+		return "", fmt.Errorf("Invalid 'granularity' parameter %v: use 'file' or 'section'", raw)
+	}
+}
+
+// headingSection is one heading-bounded chunk of a Markdown file: the
+// 0-based line range [start,end), its heading text and anchor. The chunk
+// before the first "## " heading has no anchor; its heading is the page's
+// "# " title, if any.
+type headingSection struct {
+	start, end      int
+	heading, anchor string
+}
+
+// headingSections splits lines into the preamble, each "## " section up to
+// its first "### ", and each "### " section, using the same boundaries as
+// h2Range and h3Range. A file without "## " headings yields nil: it is one
+// section, and ranks as a file.
+func headingSections(lines []string) []headingSection {
+	var sections []headingSection
+	for i := 0; i < len(lines); i++ {
+		if _, isH2 := h2Heading(lines[i]); !isH2 {
+			continue
+		}
+		if len(sections) == 0 && i > 0 {
+			sections = append(sections, headingSection{start: 0, end: i, heading: pageTitle(lines[:i])})
+		}
+		end := h2End(lines, i)
+		sectionEnd := end
+		for j := i + 1; j < end; j++ {
+			if strings.HasPrefix(lines[j], "### ") {
+				sectionEnd = j
+				break
+			}
+		}
+		sections = append(sections, newHeadingSection(lines, i, sectionEnd))
+		for j := sectionEnd; j < end; j = h3End(lines, j, end) {
+			sections = append(sections, newHeadingSection(lines, j, h3End(lines, j, end)))
+		}
+		i = end - 1
+	}
+	return sections
+}
+
+func newHeadingSection(lines []string, start, end int) headingSection {
+	text := strings.TrimSpace(strings.TrimLeft(lines[start], "#"))
+	return headingSection{start: start, end: end, heading: stripMemberName(text), anchor: headingAnchor(text)}
+}
+
+// headingAnchor returns a heading's explicit "[#anchor]" fragment, or the
+// anchor the docs site derives from its text.
+func headingAnchor(text string) string {
+	if idx := strings.LastIndex(text, "[#"); idx >= 0 && strings.HasSuffix(text, "]") {
+		return text[idx+2 : len(text)-1]
+	}
+	return titleToAnchor(strings.Trim(text, "`"))
+}
+
+// pageTitle returns the text of the first "# " heading in lines.
+func pageTitle(lines []string) string {
+	for _, line := range lines {
+		if strings.HasPrefix(line, "# ") {
+			return stripHeadingAnchor(strings.TrimPrefix(line, "# "))
+		}
+	}
+	return ""
+}
+
+// splitIntoSections replaces each Markdown file among files with one
+// scoredFile per heading section holding any of its snippets, each with
+// its own term coverage, matched as addFileHit matches file coverage (term
+// or synonym), so scorers rank sections on their own hits. The
+// filename pseudo-hit goes with the preamble. File-level marks (deprecation)
+// carry over to every section. Files that are not split pass through.
+func splitIntoSections(files []*scoredFile, corpus *corpusStats, queryTerms []string, synonyms map[string][]string) []*scoredFile {
+	out := make([]*scoredFile, 0, len(files))
+	for _, sf := range files {
+		doc := corpus.docs[sf.AbsPath]
+		if doc == nil || !hasAllowedExt(sf.AbsPath, []string{".md", ".mdx"}) {
+			out = append(out, sf)
+			continue
+		}
+		sections := headingSections(doc.file.Lines)
+		if len(sections) == 0 {
+			out = append(out, sf)
+			continue
+		}
+		chunks := make([]*scoredFile, len(sections))
+		for _, snip := range sf.Snippets {
+			i := 0
+			for i < len(sections)-1 && snip.Line > sections[i].end {
+				i++
+			}
+			if chunks[i] == nil {
+				sec := sections[i]
+				chunks[i] = &scoredFile{
+					RelPath:         sf.RelPath,
+					AbsPath:         sf.AbsPath,
+					Section:         sf.Section,
+					Deprecated:      sf.Deprecated,
+					ReplacementText: sf.ReplacementText,
+					ReplacementLink: sf.ReplacementLink,
+					TermsFound:      make(map[string]bool),
+					StartLine:       sec.start + 1,
+					EndLine:         sec.end,
+					Heading:         sec.heading,
+					Anchor:          sec.anchor,
+				}
+			}
+			chunk := chunks[i]
+			chunk.Snippets = append(chunk.Snippets, snip)
+			lower := strings.ToLower(snip.Text)
+			for _, term := range queryTerms {
+				if containsTermOrSynonym(lower, term, synonyms) {
+					chunk.TermsFound[term] = true
+				}
+			}
+		}
+		for _, chunk := range chunks {
+			if chunk != nil {
+				out = append(out, chunk)
+			}
+		}
+	}
+	return out
+}
+
+// lines returns the lines sf covers: its section's, or the whole file's.
+func (sf *scoredFile) lines(file *indexedFile) []string {
+	if sf.EndLine == 0 || sf.EndLine > len(file.Lines) {
+		return file.Lines
+	}
+	return file.Lines[sf.StartLine-1 : sf.EndLine]
+}
+
+// sectionURL is the documentation URL of a section result: the page URL
+// with the section's anchor, or "" when the file has no docs page.
+func sectionURL(sf *scoredFile) string {
+	page := constructDocURL(sf.RelPath)
+	if page == "" || sf.Anchor == "" {
+		return page
+	}
+	return page + "#" + sf.Anchor
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHeadingSectionsFollowH2AndH3Bounds(t *testing.T) {
+	lines := []string{
+		"# Table",                     // 0
+		"Intro.",                      // 1
+		"## Properties [#properties]", // 2
+		"Shared prose.",               // 3
+		"### `sortBy` [#sortby]",      // 4
+		"Sorts rows.",                 // 5
+		"### `pageSize`",              // 6
+		"Rows per page.",              // 7
+		"## Styling",                  // 8
+		"Theme variables.",            // 9
+	}
+	got := headingSections(lines)
+	want := []headingSection{
+		{start: 0, end: 2, heading: "Table"},
+		{start: 2, end: 4, heading: "Properties", anchor: "properties"},
+		{start: 4, end: 6, heading: "sortBy", anchor: "sortby"},
+		{start: 6, end: 8, heading: "pageSize", anchor: "pagesize"},
+		{start: 8, end: 10, heading: "Styling", anchor: "styling"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("sections = %+v\nwant %+v", got, want)
+	}
+	if headingSections([]string{"# Title", "No sections here."}) != nil {
+		t.Fatal("a file without ## headings was split")
+	}
+}
+
+func TestSectionGranularityRanksTheAnsweringSection(t *testing.T) {
+	resetTopicIndexForTest(t)
+	root := t.TempDir()
+	howtoDir := filepath.Join(root, "howto")
+	if err := os.MkdirAll(howtoDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeHowtoFixture(t, howtoDir, "guide.md", strings.Join([]string{
+		"# A long guide",
+		"## Layout",
+		"Stack children vertically.",
+		"## Validation",
+		"Validate each field before submit.",
+		"## Debounce",
+		"Debounce the search input.",
+		"## Sticky header",
+		"Keep the header visible while scrolling.",
+	}, "\n")+"\n")
+
+	cfg := howtoMediatorConfig(howtoDir)
+	cfg.Granularity = SearchGranularitySection
+	human, summary, err := ExecuteMediatedSearch(root, cfg, "debounce search input")
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := summary.Sections["howtos"]
+	if len(items) == 0 {
+		t.Fatalf("no results:\n%s", human)
+	}
+	top := items[0]
+	if top.Heading != "Debounce" || top.SectionStartLine != 6 || top.SectionEndLine != 7 {
+		t.Fatalf("top section = %q L%d-%d, want Debounce L6-7", top.Heading, top.SectionStartLine, top.SectionEndLine)
+	}
+	if top.URL != HowtoURL("guide")+"#debounce" {
+		t.Fatalf("url = %q", top.URL)
+	}
+	if !strings.Contains(human, "## howto/guide.md § Debounce  (L6-7,") {
+		t.Fatalf("human block lacks the section header:\n%s", human)
+	}
+}
+
+// A section covers a term through a synonym exactly as its file does.
+func TestSectionCoverageMatchesFileCoverage(t *testing.T) {
+	abs := filepath.Join(t.TempDir(), "guide.md")
+	lines := []string{"# Guide", "## Overlays", "Open a dialog over the page."}
+	corpus := &corpusStats{docs: map[string]*corpusDoc{
+		abs: {file: &indexedFile{Path: abs, Lines: lines}},
+	}}
+	file := &scoredFile{
+		RelPath:    "guide.md",
+		AbsPath:    abs,
+		TermsFound: map[string]bool{"modal": true},
+		Snippets:   []scoredSnippet{{Line: 3, Text: lines[2]}},
+	}
+	synonyms := map[string][]string{"modal": {"dialog"}}
+	chunks := splitIntoSections([]*scoredFile{file}, corpus, []string{"modal"}, synonyms)
+	if len(chunks) != 1 || chunks[0].Heading != "Overlays" {
+		t.Fatalf("chunks = %+v", chunks)
+	}
+	if !reflect.DeepEqual(chunks[0].TermsFound, file.TermsFound) {
+		t.Fatalf("section terms %v, file terms %v", chunks[0].TermsFound, file.TermsFound)
+	}
+}
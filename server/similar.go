@@ -0,0 +1,298 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	defaultSimilarLimit = 5
+	maxSimilarLimit     = 20
+	// minSimilarWordLength drops two-letter words: they are mostly markup
+	// and abbreviations that tie unrelated pages together.
+	minSimilarWordLength = 3
+	// similarSharedTerms is how many of the heaviest shared terms each
+	// result lists, so the agent can see why two pages were paired.
+	similarSharedTerms = 5
+)
+
+// similarDoc is one documentation page as a TF-IDF vector, L2-normalized so
+// a dot product is the cosine similarity.
+type similarDoc struct {
+	relPath string
+	title   string
+	vector  map[string]float64
+}
+
+// similarModel is the vector space over the docs roots' Markdown pages. It
+// is rebuilt when any of the underlying indexes is.
+type similarModel struct {
+	key    string
+	docs   []similarDoc
+	byPath map[string]int
+}
+
+var (
+	similarMu    sync.Mutex
+	similarCache *similarModel
+)
+
+// docsIndexExtensions is xmlui_search's extension list. Tools that read the
+// docs roots index them with it, so they share the search tool's indexes
+// instead of building their own.
+var docsIndexExtensions = []string{".mdx", ".md", ".tsx", ".scss"}
+
+func NewSimilarTool(homeDir string) (mcp.Tool, func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)) {
+	tool := mcp.NewTool("xmlui_similar",
+		mcp.WithDescription("Finds documentation pages similar to a given page (\"more like this\"), by TF-IDF cosine similarity over the docs corpus. Works offline. Returns each page's path, title, URL, similarity, and the terms it shares with the given page."),
+		mcp.WithString("path",
+			mcp.Required(),
+			mcp.Description("Repo-relative path of a .md or .mdx docs page, as returned in a search result's path, e.g. 'docs/content/components/Table.md'"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description(fmt.Sprintf("Optional number of pages to return, 1 to %d (default %d)", maxSimilarLimit, defaultSimilarLimit)),
+		),
+	)
+
+	tool.Annotations = mcp.ToolAnnotation{
+		ReadOnlyHint:    true,
+		DestructiveHint: false,
+		IdempotentHint:  true,
+		OpenWorldHint:   false,
+	}
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		relPath, ok := req.Params.Arguments["path"].(string)
+		if !ok || strings.TrimSpace(relPath) == "" {
+			return mcp.NewToolResultError("Missing or invalid 'path' parameter"), nil
+		}
+		relPath = filepath.ToSlash(filepath.Clean(strings.TrimSpace(relPath)))
+		limit, err := similarLimitArgument(req)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		model := similarModelFor(ctx, homeDir)
+		if ctx.Err() != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Similarity search %s before the docs were indexed", searchTruncation(ctx))), nil
+		}
+		if _, ok := model.byPath[relPath]; !ok {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid 'path' parameter %q: not a .md or .mdx page under the component docs, pages, or blog directories", relPath)), nil
+		}
+		return mcp.NewToolResultText(formatSimilar(relPath, model.nearest(relPath, limit))), nil
+	}
+
+	return tool, handler
+}
+
+// similarLimitArgument reads the optional "limit" argument.
+func similarLimitArgument(req mcp.CallToolRequest) (int, error) {
+	raw, ok := req.Params.Arguments["limit"]
+	if !ok || raw == nil {
+		return defaultSimilarLimit, nil
+	}
+	n, ok := raw.(float64)
+	if !ok || n < 1 || n > maxSimilarLimit || n != float64(int(n)) {
+		return 0, fmt.Errorf("Invalid 'limit' parameter: must be an integer from 1 to %d", maxSimilarLimit)
+	}
+	return int(n), nil
+}
+
+// similarRoots are the directories whose pages the model covers.
+func similarRoots(homeDir string) []string {
+	paths := GetRepoPaths(homeDir)
+	var roots []string
+	for _, dir := range []string{paths.ComponentDocs, paths.Pages, paths.Blog} {
+		if dir != "" {
+			roots = append(roots, filepath.Join(homeDir, dir))
+		}
+	}
+	return roots
+}
+
+// similarModelFor returns the model for the current indexes, building it
+// when one of them has changed since the last call.
+func similarModelFor(ctx context.Context, homeDir string) *similarModel {
+	var indexes []*searchIndex
+	var key strings.Builder
+	for _, root := range similarRoots(homeDir) {
+		idx := searchIndexFor(ctx, homeDir, root, docsIndexExtensions)
+		if idx == nil {
+			continue
+		}
+		indexes = append(indexes, idx)
+		key.WriteString(strconv.FormatUint(idx.generation, 10))
+		key.WriteByte(',')
+	}
+
+	similarMu.Lock()
+	defer similarMu.Unlock()
+	if similarCache != nil && similarCache.key == key.String() {
+		return similarCache
+	}
+	model := buildSimilarModel(homeDir, indexes, CurrentSearchDictionary().Stopwords)
+	model.key = key.String()
+	if ctx.Err() == nil {
+		similarCache = model
+	}
+	return model
+}
+
+// buildSimilarModel vectorizes the .md and .mdx pages, weighting each term
+// by (1 + log tf) × log(N / df): sublinear in-page frequency, so a page
+// repeating "Table" fifty times is not fifty times about tables, and zero
+// for terms on every page.
+func buildSimilarModel(homeDir string, indexes []*searchIndex, stopwords map[string]struct{}) *similarModel {
+	model := &similarModel{byPath: make(map[string]int)}
+	var counts []map[string]int
+	df := make(map[string]int)
+	for _, idx := range indexes {
+		for i := range idx.Files {
+			file := &idx.Files[i]
+			if !hasAllowedExt(file.Path, []string{".md", ".mdx"}) {
+				continue
+			}
+			rel := toRepoRelative(homeDir, file.Path)
+			if _, seen := model.byPath[rel]; seen {
+				continue
+			}
+			tf := similarTerms(file.Lines, stopwords)
+			for term := range tf {
+				df[term]++
+			}
+			title := pageTitle(file.Lines)
+			if title == "" {
+				title = strings.TrimSuffix(file.Name, filepath.Ext(file.Name))
+			}
+			model.byPath[rel] = len(model.docs)
+			model.docs = append(model.docs, similarDoc{relPath: rel, title: title})
+			counts = append(counts, tf)
+		}
+	}
+
+	n := float64(len(model.docs))
+	for i, tf := range counts {
+		vector := make(map[string]float64, len(tf))
+		norm := 0.0
+		for term, count := range tf {
+			w := (1 + math.Log(float64(count))) * math.Log(n/float64(df[term]))
+			if w <= 0 {
+				continue
+			}
+			vector[term] = w
+			norm += w * w
+		}
+		norm = math.Sqrt(norm)
+		for term := range vector {
+			vector[term] /= norm
+		}
+		model.docs[i].vector = vector
+	}
+	return model
+}
+
+// similarTerms counts a page's words, lowercased and stemmed the way query
+// terms are, without stopwords, short words, or bare numbers.
+func similarTerms(lines []string, stopwords map[string]struct{}) map[string]int {
+	tf := make(map[string]int)
+	for _, line := range lines {
+		for _, word := range strings.FieldsFunc(line, isNotWordRune) {
+			if len(word) < minSimilarWordLength {
+				continue
+			}
+			if _, err := strconv.Atoi(word); err == nil {
+				continue
+			}
+			word = strings.ToLower(word)
+			if _, stop := stopwords[word]; stop {
+				continue
+			}
+			tf[termStem(word)]++
+		}
+	}
+	return tf
+}
+
+// similarResult is one neighbour of the queried page.
+type similarResult struct {
+	RelPath    string
+	Title      string
+	URL        string
+	Similarity float64
+	Shared     []string
+}
+
+// nearest returns up to limit pages by descending cosine similarity to the
+// page at relPath, ties by path, leaving out the page itself and pages
+// sharing no weighted term with it.
+func (m *similarModel) nearest(relPath string, limit int) []similarResult {
+	query := m.docs[m.byPath[relPath]].vector
+	var results []similarResult
+	for _, doc := range m.docs {
+		if doc.relPath == relPath {
+			continue
+		}
+		type contribution struct {
+			term  string
+			value float64
+		}
+		var shared []contribution
+		score := 0.0
+		for term, w := range query {
+			if v, ok := doc.vector[term]; ok {
+				score += w * v
+				shared = append(shared, contribution{term, w * v})
+			}
+		}
+		if score <= 0 {
+			continue
+		}
+		sort.Slice(shared, func(i, j int) bool {
+			if shared[i].value == shared[j].value {
+				return shared[i].term < shared[j].term
+			}
+			return shared[i].value > shared[j].value
+		})
+		r := similarResult{RelPath: doc.relPath, Title: doc.title, URL: constructDocURL(doc.relPath), Similarity: score}
+		for i := 0; i < len(shared) && i < similarSharedTerms; i++ {
+			r.Shared = append(r.Shared, shared[i].term)
+		}
+		results = append(results, r)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Similarity == results[j].Similarity {
+			return results[i].RelPath < results[j].RelPath
+		}
+		return results[i].Similarity > results[j].Similarity
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+func formatSimilar(relPath string, results []similarResult) string {
+	var out strings.Builder
+	if len(results) == 0 {
+		fmt.Fprintf(&out, "No pages share weighted terms with %s.\n", relPath)
+		return out.String()
+	}
+	fmt.Fprintf(&out, "Pages similar to %s:\n", relPath)
+	for i, r := range results {
+		fmt.Fprintf(&out, "\n%d. %s  (similarity=%.3f)\n", i+1, r.RelPath, r.Similarity)
+		fmt.Fprintf(&out, "   Title: %s\n", r.Title)
+		if r.URL != "" {
+			fmt.Fprintf(&out, "   URL: %s\n", r.URL)
+		}
+		fmt.Fprintf(&out, "   Shared terms: %s\n", strings.Join(r.Shared, ", "))
+	}
+	return out.String()
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestSimilarRanksPagesSharingRareTerms(t *testing.T) {
+	ResetRepoPaths()
+	t.Cleanup(ResetRepoPaths)
+	root := t.TempDir()
+	componentsDir := filepath.Join(root, "docs", "content", "components")
+	if err := os.MkdirAll(componentsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	pages := map[string]string{
+		"Table.md":  "# Table\nRows and columns. Sortable columns, pagination, row selection.\n",
+		"List.md":   "# List\nRows of items with pagination and grouping.\n",
+		"Button.md": "# Button\nA clickable control with an icon and a label.\n",
+		"Text.md":   "# Text\nDisplays a label.\n",
+	}
+	for name, body := range pages {
+		if err := os.WriteFile(filepath.Join(componentsDir, name), []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, handler := NewSimilarTool(root)
+	var req mcp.CallToolRequest
+	req.Params.Arguments = map[string]interface{}{"path": "docs/content/components/Table.md", "limit": float64(2)}
+	result, err := handler(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", text)
+	}
+	if !strings.Contains(text, "1. docs/content/components/List.md") || !strings.Contains(text, "URL: "+ComponentURL("List")) {
+		t.Fatalf("List should rank first with its URL:\n%s", text)
+	}
+	if strings.Contains(text, "Table.md  (") || strings.Contains(text, "Button.md") {
+		t.Fatalf("the page itself and unrelated pages should be left out:\n%s", text)
+	}
+
+	req.Params.Arguments = map[string]interface{}{"path": "docs/content/components/Missing.md"}
+	result, err = handler(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "Invalid 'path' parameter") {
+		t.Fatalf("expected an invalid path error, got %+v", result)
+	}
+}
@@ -0,0 +1,144 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxContextLines caps the context_lines argument: past it a result is a
+// file read, which xmlui_read_file serves better.
+const maxContextLines = 20
+
+// withSearchContextLines adds the shared "context_lines" argument.
+func withSearchContextLines() mcp.ToolOption {
+	return mcp.WithNumber("context_lines",
+		mcp.Description(fmt.Sprintf("Optional lines of context before and after each matched line (0-%d, default 0). Overlapping windows merge, and a window never crosses a ``` code-fence line, so a match inside an example returns that example's fragment.", maxContextLines)),
+	)
+}
+
+// searchContextLinesArgument reads the optional "context_lines" argument.
+func searchContextLinesArgument(req mcp.CallToolRequest) (int, error) {
+	raw, ok := req.Params.Arguments["context_lines"]
+	if !ok || raw == nil {
+		return 0, nil
+	}
+	n, ok := raw.(float64)
+	if !ok || n < 0 || n > maxContextLines || n != float64(int(n)) {
+		return 0, fmt.Errorf("Invalid 'context_lines' parameter: must be an integer from 0 to %d", maxContextLines)
+	}
+	return int(n), nil
+}
+
+// contextWindow is a merged, 1-based inclusive line range around one or
+// more hit lines.
+type contextWindow struct {
+	start, end int
+	hits       []int
+}
+
+// contextWindows widens each hit line by n lines each way, clipped to its
+// fence segment, then merges windows that overlap or touch. Hit lines that
+// are not real lines (0, the filename pseudo-hit) get no window.
+func contextWindows(lines []string, hitLines []int, n int) []contextWindow {
+	segments := fenceSegments(lines)
+	var windows []contextWindow
+	for _, hit := range hitLines {
+		if hit < 1 || hit > len(lines) {
+			continue
+		}
+		seg := segments[hit-1]
+		start, end := hit, hit
+		for start > 1 && hit-start < n && segments[start-2] == seg {
+			start--
+		}
+		for end < len(lines) && end-hit < n && segments[end] == seg {
+			end++
+		}
+		windows = append(windows, contextWindow{start: start, end: end, hits: []int{hit}})
+	}
+	sort.Slice(windows, func(i, j int) bool { return windows[i].start < windows[j].start })
+
+	var merged []contextWindow
+	for _, w := range windows {
+		if last := len(merged) - 1; last >= 0 && w.start <= merged[last].end+1 && segments[w.start-1] == segments[merged[last].end-1] {
+			merged[last].end = max(merged[last].end, w.end)
+			merged[last].hits = append(merged[last].hits, w.hits...)
+			continue
+		}
+		merged = append(merged, w)
+	}
+	return merged
+}
+
+// fenceSegments numbers the runs of lines between ``` fence markers. A
+// marker line gets -1, so no window can include or cross it.
+func fenceSegments(lines []string) []int {
+	segments := make([]int, len(lines))
+	seg := 0
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			segments[i] = -1
+			seg++
+			continue
+		}
+		segments[i] = seg
+	}
+	return segments
+}
+
+// withContext turns one file's result items into context-window items: the
+// items whose lines share a merged window collapse into one, anchored at
+// its first hit, carrying the window's lines. Items without a real line
+// (the filename pseudo-hit) pass through unchanged.
+func withContext(items []resultItem, file *indexedFile, n, maxLen int) []resultItem {
+	if n <= 0 || file == nil || len(items) == 0 {
+		return items
+	}
+	byLine := make(map[int]resultItem, len(items))
+	var hitLines []int
+	var out []resultItem
+	for _, item := range items {
+		if item.Line < 1 || item.Line > len(file.Lines) {
+			out = append(out, item)
+			continue
+		}
+		byLine[item.Line] = item
+		hitLines = append(hitLines, item.Line)
+	}
+	for _, w := range contextWindows(file.Lines, hitLines, n) {
+		sort.Ints(w.hits)
+		item := byLine[w.hits[0]]
+		item.StartLine, item.EndLine = w.start, w.end
+		item.Context = make([]string, 0, w.end-w.start+1)
+		for _, line := range file.Lines[w.start-1 : w.end] {
+			if len(line) > maxLen {
+				line = line[:maxLen] + "..."
+			}
+			item.Context = append(item.Context, line)
+		}
+		out = append(out, item)
+	}
+	return out
+}
+
+// writeResultItem renders one item of the human block. mark, when not nil,
+// rewrites each displayed line, e.g. to highlight its matches.
+func writeResultItem(out *strings.Builder, item resultItem, mark func(string) string) {
+	if mark == nil {
+		mark = func(line string) string { return line }
+	}
+	switch {
+	case item.Line == 0:
+		fmt.Fprintf(out, "  %s\n", mark(item.Snippet))
+	case len(item.Context) > 0:
+		fmt.Fprintf(out, "  L%d-%d:\n", item.StartLine, item.EndLine)
+		for i, line := range item.Context {
+			fmt.Fprintf(out, "    %d| %s\n", item.StartLine+i, mark(line))
+		}
+	default:
+		fmt.Fprintf(out, "  L%d: %s\n", item.Line, mark(item.Snippet))
+	}
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestContextWindowsMergeAndStopAtFences(t *testing.T) {
+	lines := []string{
+		"# Buttons",                 // 1
+		"Intro prose.",              // 2
+		"```xmlui-pg",               // 3
+		"<App>",                     // 4
+		"  <Button",                 // 5
+		"    label=\"Save\"",        // 6
+		"    onClick=\"save()\" />", // 7
+		"</App>",                    // 8
+		"```",                       // 9
+		"Closing prose.",            // 10
+	}
+	got := contextWindows(lines, []int{5, 7, 2}, 2)
+	want := []contextWindow{
+		{start: 1, end: 2, hits: []int{2}},
+		{start: 4, end: 8, hits: []int{5, 7}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("windows = %+v, want %+v", got, want)
+	}
+}
+
+func TestContextLinesReturnCompleteFragment(t *testing.T) {
+	resetTopicIndexForTest(t)
+	root := t.TempDir()
+	howtoDir := filepath.Join(root, "howto")
+	if err := os.MkdirAll(howtoDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeHowtoFixture(t, howtoDir, "save-button.md", strings.Join([]string{
+		"# Save with a button",
+		"```xmlui-pg",
+		"<App>",
+		"  <Button",
+		"    label=\"Save\"",
+		"    onClick=\"saveDraft()\" />",
+		"</App>",
+		"```",
+	}, "\n")+"\n")
+
+	cfg := howtoMediatorConfig(howtoDir)
+	cfg.ContextLines = 3
+	human, summary, err := ExecuteMediatedSearch(root, cfg, "saveDraft")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var window *resultItem
+	for i, item := range summary.Sections["howtos"] {
+		if len(item.Context) > 0 && item.Line == 6 {
+			window = &summary.Sections["howtos"][i]
+		}
+	}
+	if window == nil {
+		t.Fatalf("no context window for the hit: %+v", summary.Sections["howtos"])
+	}
+	if window.StartLine != 3 || window.EndLine != 7 || window.Context[0] != "<App>" || window.Context[4] != "</App>" {
+		t.Fatalf("window = %d-%d %q, want the fenced fragment 3-7", window.StartLine, window.EndLine, window.Context)
+	}
+	if !strings.Contains(human, "  L3-7:\n    3| <App>\n") {
+		t.Fatalf("human block lacks the window:\n%s", human)
+	}
+}
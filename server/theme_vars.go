@@ -0,0 +1,470 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+var (
+	// scssStringVarRe matches a string variable, e.g. `$component: "Button";`.
+	scssStringVarRe = regexp.MustCompile(`^\s*\$([\w-]+)\s*:\s*["']([^"']*)["']`)
+	// createThemeVarRe matches a theme variable declaration in a component
+	// stylesheet, e.g. `createThemeVar("backgroundColor-#{$component}")`.
+	createThemeVarRe    = regexp.MustCompile(`createThemeVar\(\s*["']([^"']+)["']`)
+	scssInterpolationRe = regexp.MustCompile(`#\{\$([\w-]+)\}`)
+	// scssListVarRe matches a list variable, e.g. `$variants: primary, secondary;`.
+	scssListVarRe = regexp.MustCompile(`^\s*\$([\w-]+)\s*:\s*\(?([^;:()]+,[^;:()]*)\)?\s*(?:!default\s*)?;`)
+	// scssEachRe matches a loop over a list, e.g. `@each $variant in $variants {`.
+	scssEachRe = regexp.MustCompile(`^\s*@each\s+\$([\w-]+)\s+in\s+([^{]+)\{`)
+	scssWordRe = regexp.MustCompile(`^[\w-]+$`)
+)
+
+// themeVarSite is where a stylesheet declares a theme variable.
+type themeVarSite struct {
+	Path string // repo-relative
+	Line int
+}
+
+// themeVar is one theme variable: its defaults and docs anchor from the
+// first reference page that lists it, the stylesheets that declare it, and
+// every component that lists or declares it.
+type themeVar struct {
+	Name         string
+	Default      string
+	DarkDefault  string
+	DocComponent string
+	DocPath      string
+	DocLine      int
+	URL          string
+	Sites        []themeVarSite
+	Consumers    []string
+}
+
+// themeVarRegistry is every theme variable of the corpus, by name. It is
+// rebuilt when the component docs or sources index is.
+type themeVarRegistry struct {
+	key         string
+	byName      map[string]*themeVar
+	byComponent map[string][]*themeVar // docs order
+	urls        map[string]string      // component → its theme variables section
+	components  map[string]bool        // every component listing or declaring one
+
+	// Lowercased names, for case-insensitive lookups. When two names fold
+	// to one, the first in sorted order wins.
+	foldedNames      map[string]*themeVar
+	foldedComponents map[string]string
+
+	// Declarations whose names are built from something the stylesheet
+	// does not resolve (mixin arguments, map loops), by the documented
+	// component whose stylesheet holds them, and in total.
+	skipped      map[string]int
+	skippedTotal int
+}
+
+var (
+	themeVarMu    sync.Mutex
+	themeVarCache = map[string]*themeVarRegistry{} // by corpus tag
+)
+
+// themeVarRegistryFor returns the registry of homeDir's corpus, rebuilding
+// it when the corpus tag is new or an underlying index has changed. The
+// source index is xmlui_search's, which covers .scss.
+func themeVarRegistryFor(ctx context.Context, homeDir string) *themeVarRegistry {
+	paths := GetRepoPaths(homeDir)
+	docs := componentDocsIndexes(ctx, homeDir)
+	source := searchIndexFor(ctx, homeDir, filepath.Join(homeDir, paths.ComponentSource), docsIndexExtensions)
+	var key strings.Builder
+	key.WriteString(homeDir)
+	for _, idx := range append(append([]*searchIndex(nil), docs...), source) {
+		key.WriteByte('|')
+		if idx != nil {
+			key.WriteString(strconv.FormatUint(idx.generation, 10))
+		}
+	}
+
+	tag := corpusVersionForDir(homeDir)
+
+	themeVarMu.Lock()
+	defer themeVarMu.Unlock()
+	if cached := themeVarCache[tag]; cached != nil && cached.key == key.String() {
+		return cached
+	}
+	registry := buildThemeVarRegistry(homeDir, documentedComponentSchemas(docs), source)
+	registry.key = key.String()
+	if ctx.Err() == nil {
+		themeVarCache[tag] = registry
+	}
+	return registry
+}
+
+// buildThemeVarRegistry reads the reference pages' theme variable tables in
+// docs' precedence order, then the stylesheets under source. A stylesheet
+// makes its directory a consumer only when that directory is a documented
+// component, so shared theming stylesheets add definition sites but no
+// bogus components.
+func buildThemeVarRegistry(homeDir string, docs []documentedSchema, source *searchIndex) *themeVarRegistry {
+	registry := &themeVarRegistry{byName: make(map[string]*themeVar), byComponent: make(map[string][]*themeVar), urls: make(map[string]string), skipped: make(map[string]int)}
+	entry := func(name string) *themeVar {
+		v := registry.byName[name]
+		if v == nil {
+			v = &themeVar{Name: name}
+			registry.byName[name] = v
+		}
+		return v
+	}
+	consume := func(v *themeVar, component string) {
+		if !containsString(v.Consumers, component) {
+			v.Consumers = append(v.Consumers, component)
+		}
+	}
+
+	documented := make(map[string]bool, len(docs))
+	for _, doc := range docs {
+		documented[doc.schema.Component] = true
+	}
+	for _, doc := range docs {
+		schema := doc.schema
+		rel := toRepoRelative(homeDir, doc.path)
+		for _, row := range schema.ThemeVars {
+			v := entry(row.Name)
+			if v.DocComponent == "" {
+				v.Default, v.DarkDefault = row.Default, row.DarkDefault
+				v.DocComponent, v.DocPath, v.DocLine = schema.Component, rel, row.Line
+				v.URL = schema.URL
+				if row.Anchor != "" {
+					v.URL += "#" + row.Anchor
+				}
+			}
+			consume(v, schema.Component)
+			if !containsThemeVar(registry.byComponent[schema.Component], v) {
+				registry.byComponent[schema.Component] = append(registry.byComponent[schema.Component], v)
+			}
+			if registry.urls[schema.Component] == "" {
+				registry.urls[schema.Component] = v.URL
+			}
+		}
+	}
+
+	if source != nil {
+		for i := range source.Files {
+			file := &source.Files[i]
+			if filepath.Ext(file.Name) != ".scss" {
+				continue
+			}
+			rel := toRepoRelative(homeDir, file.Path)
+			component := filepath.Base(filepath.Dir(file.Path))
+			if !documented[component] {
+				component = ""
+			}
+			sites, skipped := scssThemeVars(file.Lines)
+			for _, site := range sites {
+				v := entry(site.name)
+				v.Sites = append(v.Sites, themeVarSite{Path: rel, Line: site.line})
+				if component != "" {
+					consume(v, component)
+				}
+			}
+			registry.skippedTotal += skipped
+			if component != "" && skipped > 0 {
+				registry.skipped[component] += skipped
+			}
+		}
+	}
+	registry.foldedNames = make(map[string]*themeVar, len(registry.byName))
+	registry.foldedComponents = make(map[string]string)
+	registry.components = make(map[string]bool)
+	for _, name := range sortedKeys(registry.byName) {
+		v := registry.byName[name]
+		sort.Strings(v.Consumers)
+		if key := strings.ToLower(name); registry.foldedNames[key] == nil {
+			registry.foldedNames[key] = v
+		}
+		for _, c := range v.Consumers {
+			registry.components[c] = true
+		}
+	}
+	for _, c := range sortedKeys(registry.components) {
+		if key := strings.ToLower(c); registry.foldedComponents[key] == "" {
+			registry.foldedComponents[key] = c
+		}
+	}
+	return registry
+}
+
+func containsThemeVar(vars []*themeVar, v *themeVar) bool {
+	for _, existing := range vars {
+		if existing == v {
+			return true
+		}
+	}
+	return false
+}
+
+// scssThemeVar is one createThemeVar declaration in a stylesheet.
+type scssThemeVar struct {
+	name string
+	line int
+}
+
+// scssThemeVars lists a stylesheet's createThemeVar declarations, with
+// "#{$var}" interpolations resolved from the file's string variables and
+// from the enclosing @each loops over literal lists (or list variables),
+// one declaration per loop value. Declarations that stay unresolved, such
+// as names built from mixin arguments, are counted in skipped.
+func scssThemeVars(lines []string) (out []scssThemeVar, skipped int) {
+	vars := make(map[string]string)
+	lists := make(map[string][]string)
+	type loop struct {
+		name   string
+		values []string
+		depth  int
+	}
+	var loops []loop
+	depth := 0
+	for i, line := range lines {
+		if m := scssStringVarRe.FindStringSubmatch(line); m != nil {
+			vars[m[1]] = m[2]
+		} else if m := scssListVarRe.FindStringSubmatch(line); m != nil {
+			if values := scssListValues(m[2], nil); values != nil {
+				lists[m[1]] = values
+			}
+		}
+		if m := scssEachRe.FindStringSubmatch(line); m != nil {
+			loops = append(loops, loop{name: m[1], values: scssListValues(m[2], lists), depth: depth + 1})
+		}
+		for _, m := range createThemeVarRe.FindAllStringSubmatch(line, -1) {
+			names := []string{scssInterpolationRe.ReplaceAllStringFunc(m[1], func(ref string) string {
+				if value, ok := vars[scssInterpolationRe.FindStringSubmatch(ref)[1]]; ok {
+					return value
+				}
+				return ref
+			})}
+			for _, l := range loops {
+				ref := "#{$" + l.name + "}"
+				if !strings.Contains(names[0], ref) {
+					continue
+				}
+				var expanded []string
+				for _, name := range names {
+					for _, value := range l.values {
+						expanded = append(expanded, strings.ReplaceAll(name, ref, value))
+					}
+				}
+				names = expanded
+			}
+			if len(names) == 0 || strings.Contains(names[0], "#{") {
+				skipped++
+				continue
+			}
+			for _, name := range names {
+				out = append(out, scssThemeVar{name, i + 1})
+			}
+		}
+		depth += strings.Count(line, "{") - strings.Count(line, "}")
+		for len(loops) > 0 && loops[len(loops)-1].depth > depth {
+			loops = loops[:len(loops)-1]
+		}
+	}
+	return out, skipped
+}
+
+// scssListValues splits a literal list ("primary, secondary" or
+// "(primary secondary)") into its values, or looks up a list variable in
+// lists. It returns nil for anything else, e.g. a map.
+func scssListValues(expr string, lists map[string][]string) []string {
+	expr = strings.Trim(strings.TrimSpace(expr), "()")
+	if strings.HasPrefix(expr, "$") {
+		return lists[strings.TrimPrefix(expr, "$")]
+	}
+	var values []string
+	for _, field := range strings.FieldsFunc(expr, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+		value := strings.Trim(field, `"'`)
+		if !scssWordRe.MatchString(value) {
+			return nil
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
+// lookup returns the variable named name, case-insensitively.
+func (r *themeVarRegistry) lookup(name string) *themeVar {
+	if v := r.byName[name]; v != nil {
+		return v
+	}
+	return r.foldedNames[strings.ToLower(name)]
+}
+
+// component returns the variables a component's reference page lists, in
+// page order, then the others its stylesheets declare, sorted by name.
+func (r *themeVarRegistry) component(name string) (string, []*themeVar) {
+	canonical := name
+	if !r.components[name] {
+		if c := r.foldedComponents[strings.ToLower(name)]; c != "" {
+			canonical = c
+		}
+	}
+	vars := append([]*themeVar(nil), r.byComponent[canonical]...)
+	var undocumented []*themeVar
+	for _, v := range r.byName {
+		if containsString(v.Consumers, canonical) && !containsThemeVar(vars, v) {
+			undocumented = append(undocumented, v)
+		}
+	}
+	sort.Slice(undocumented, func(i, j int) bool { return undocumented[i].Name < undocumented[j].Name })
+	return canonical, append(vars, undocumented...)
+}
+
+func NewThemeVarsTool(homeDir string) (mcp.Tool, func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)) {
+	tool := mcp.NewTool("xmlui_theme_vars",
+		mcp.WithDescription("Explores XMLUI theme variables instead of guessing their names. With 'component', lists that component's theme variables with light and dark defaults, the stylesheet declaring each, and the other components using it. With 'name', looks one variable up (e.g. 'backgroundColor-Button'): its defaults, docs anchor, definition site and consumers. With neither, lists the components that have theme variables."),
+		mcp.WithString("component",
+			mcp.Description("Optional component name, e.g. 'Button'. Case-insensitive."),
+		),
+		mcp.WithString("name",
+			mcp.Description("Optional theme variable name, e.g. 'backgroundColor-Button'. Case-insensitive."),
+		),
+	)
+
+	tool.Annotations = mcp.ToolAnnotation{
+		ReadOnlyHint:    true,
+		DestructiveHint: false,
+		IdempotentHint:  true,
+		OpenWorldHint:   false,
+	}
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		component, _ := req.Params.Arguments["component"].(string)
+		component = normalizeComponentArg(component)
+		name, _ := req.Params.Arguments["name"].(string)
+		name = strings.TrimSpace(name)
+		if component != "" && name != "" {
+			return mcp.NewToolResultError("Pass either 'component' or 'name', not both"), nil
+		}
+
+		registry := themeVarRegistryFor(ctx, homeDir)
+		if ctx.Err() != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Theme variable lookup %s before the component docs and sources were indexed", searchTruncation(ctx))), nil
+		}
+		switch {
+		case name != "":
+			return mcp.NewToolResultText(formatThemeVarLookup(registry, name)), nil
+		case component != "":
+			return mcp.NewToolResultText(formatComponentThemeVars(registry, component)), nil
+		}
+		return mcp.NewToolResultText(formatThemeVarComponents(registry)), nil
+	}
+
+	return tool, handler
+}
+
+func formatThemeVarLookup(registry *themeVarRegistry, name string) string {
+	var out strings.Builder
+	v := registry.lookup(name)
+	if v == nil {
+		fmt.Fprintf(&out, "No theme variable named %s.", name)
+		if suggestions := closestNames(name, sortedKeys(registry.byName)); len(suggestions) > 0 {
+			fmt.Fprintf(&out, " Did you mean: %s?", strings.Join(suggestions, ", "))
+		}
+		out.WriteString("\n")
+		return out.String()
+	}
+	out.WriteString(v.Name + "\n")
+	writeThemeVarDetails(&out, v, "  ", "")
+	return out.String()
+}
+
+func formatComponentThemeVars(registry *themeVarRegistry, name string) string {
+	var out strings.Builder
+	component, vars := registry.component(name)
+	if len(vars) == 0 {
+		fmt.Fprintf(&out, "No theme variables recorded for %s.\n", component)
+		writeSkippedThemeVars(&out, registry.skipped[component], component+"'s stylesheets")
+		return out.String()
+	}
+	fmt.Fprintf(&out, "Theme variables of %s (%d):\n", component, len(vars))
+	if url := registry.urls[component]; url != "" {
+		fmt.Fprintf(&out, "URL: %s\n", url)
+	}
+	writeSkippedThemeVars(&out, registry.skipped[component], component+"'s stylesheets")
+	for _, v := range vars {
+		out.WriteString("\n- " + v.Name + "\n")
+		writeThemeVarDetails(&out, v, "    ", component)
+	}
+	return out.String()
+}
+
+// writeThemeVarDetails writes v's defaults, docs location, declaration
+// sites and consumers, leaving out the component being listed.
+func writeThemeVarDetails(out *strings.Builder, v *themeVar, indent, listing string) {
+	if v.DocComponent != "" {
+		def, dark := v.Default, v.DarkDefault
+		if def == "" {
+			def = "(none)"
+		}
+		if dark == "" {
+			dark = "(none)"
+		}
+		fmt.Fprintf(out, "%sDefault: %s; dark: %s\n", indent, def, dark)
+		if listing == "" {
+			fmt.Fprintf(out, "%sDocs: %s:%d\n", indent, v.DocPath, v.DocLine)
+			if v.URL != "" {
+				fmt.Fprintf(out, "%sURL: %s\n", indent, v.URL)
+			}
+		}
+	} else {
+		fmt.Fprintf(out, "%sNot documented on a reference page\n", indent)
+	}
+	for _, site := range v.Sites {
+		fmt.Fprintf(out, "%sDefined: %s:%d\n", indent, site.Path, site.Line)
+	}
+	var others []string
+	for _, c := range v.Consumers {
+		if c != listing {
+			others = append(others, c)
+		}
+	}
+	if len(others) > 0 {
+		label := "Used by"
+		if listing != "" {
+			label = "Also used by"
+		}
+		fmt.Fprintf(out, "%s%s: %s\n", indent, label, strings.Join(others, ", "))
+	}
+}
+
+func formatThemeVarComponents(registry *themeVarRegistry) string {
+	var out strings.Builder
+	counts := make(map[string]int)
+	for _, v := range registry.byName {
+		for _, c := range v.Consumers {
+			counts[c]++
+		}
+	}
+	if len(counts) == 0 {
+		return "No theme variables recorded.\n"
+	}
+	fmt.Fprintf(&out, "Components with theme variables (%d; %d variables):\n", len(counts), len(registry.byName))
+	for _, c := range sortedKeys(counts) {
+		fmt.Fprintf(&out, "- %s (%d)\n", c, counts[c])
+	}
+	writeSkippedThemeVars(&out, registry.skippedTotal, "the stylesheets")
+	out.WriteString("\nPass 'component' to list one component's variables, or 'name' to look one up.\n")
+	return out.String()
+}
+
+// writeSkippedThemeVars notes declarations left out because their names
+// could not be resolved, so the listed definitions are not taken as all.
+func writeSkippedThemeVars(out *strings.Builder, skipped int, where string) {
+	if skipped > 0 {
+		fmt.Fprintf(out, "Note: %d createThemeVar declaration(s) in %s build their names from mixin arguments or other values that could not be resolved, and are not listed.\n", skipped, where)
+	}
+}
@@ -0,0 +1,183 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const themeButtonScss = `@use "../../components-core/theming/themes" as t;
+
+$component: "Button";
+$themeVars: ();
+@function createThemeVar($componentVariable) {
+  $themeVars: t.appendThemeVar($themeVars, $componentVariable) !global;
+  @return t.getThemeVar($themeVars, $componentVariable);
+}
+
+$backgroundColor-Button: createThemeVar("backgroundColor-#{$component}");
+$gap-Button: createThemeVar("gap-#{$component}");
+$outline: createThemeVar("outlineColor-#{$unknown}");
+`
+
+func TestScssThemeVarsResolvesInterpolation(t *testing.T) {
+	got, skipped := scssThemeVars(strings.Split(themeButtonScss, "\n"))
+	if len(got) != 2 || got[0] != (scssThemeVar{"backgroundColor-Button", 10}) || got[1] != (scssThemeVar{"gap-Button", 11}) || skipped != 1 {
+		t.Fatalf("declarations = %+v, skipped %d", got, skipped)
+	}
+}
+
+func TestScssThemeVarsExpandsEachLoops(t *testing.T) {
+	src := `$component: "Button";
+$variants: primary, secondary;
+@each $variant in $variants {
+  @each $state in (hover active) {
+    $bg: createThemeVar("backgroundColor-#{$component}-#{$variant}--#{$state}");
+  }
+  $fg: createThemeVar("textColor-#{$component}-#{$variant}");
+}
+@mixin sized($size) {
+  $pad: createThemeVar("padding-#{$component}-#{$size}");
+}
+$after: createThemeVar("gap-#{$variant}");`
+	got, skipped := scssThemeVars(strings.Split(src, "\n"))
+	var names []string
+	for _, v := range got {
+		names = append(names, fmt.Sprintf("%s:%d", v.name, v.line))
+	}
+	want := []string{
+		"backgroundColor-Button-primary--hover:5", "backgroundColor-Button-primary--active:5",
+		"backgroundColor-Button-secondary--hover:5", "backgroundColor-Button-secondary--active:5",
+		"textColor-Button-primary:7", "textColor-Button-secondary:7",
+	}
+	if strings.Join(names, " ") != strings.Join(want, " ") || skipped != 2 {
+		t.Fatalf("declarations = %v, skipped %d", names, skipped)
+	}
+}
+
+func setupThemeVarFixture(t *testing.T) string {
+	t.Helper()
+	ResetRepoPaths()
+	t.Cleanup(ResetRepoPaths)
+	root := t.TempDir()
+	files := map[string]string{
+		"docs/content/components/Button.md":                schemaButtonDoc,
+		"docs/content/components/IconButton.md":            "# IconButton\n",
+		"xmlui/src/components/Button/Button.module.scss":   themeButtonScss,
+		"xmlui/src/components/IconButton/Icon.module.scss": "$backgroundColor: createThemeVar(\"backgroundColor-Button\");\n",
+		// A shared stylesheet outside any component directory.
+		"xmlui/src/components/theming/_common.scss": "$focus: createThemeVar(\"outlineColor-Button\");\n",
+	}
+	for rel, body := range files {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestThemeVarsTool(t *testing.T) {
+	root := setupThemeVarFixture(t)
+	_, handler := NewThemeVarsTool(root)
+	call := func(args map[string]interface{}) string {
+		t.Helper()
+		var req mcp.CallToolRequest
+		req.Params.Arguments = args
+		result, err := handler(context.Background(), req)
+		if err != nil || result.IsError {
+			t.Fatalf("unexpected error: %v %+v", err, result)
+		}
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	text := call(map[string]interface{}{"name": "backgroundcolor-button"})
+	want := "backgroundColor-Button\n" +
+		"  Default: $color-primary-500; dark: $color-primary-400\n" +
+		"  Docs: docs/content/components/Button.md:67\n" +
+		"  URL: " + ComponentURL("Button") + "#theme-variables\n" +
+		"  Defined: xmlui/src/components/Button/Button.module.scss:10\n" +
+		"  Defined: xmlui/src/components/IconButton/Icon.module.scss:1\n" +
+		"  Used by: Button, IconButton\n"
+	if text != want {
+		t.Fatalf("lookup:\n%s\nwant:\n%s", text, want)
+	}
+
+	text = call(map[string]interface{}{"component": "Button"})
+	for _, s := range []string{
+		"Theme variables of Button (3):\n",
+		"\n- backgroundColor-Button\n    Default: $color-primary-500; dark: $color-primary-400\n    Defined: xmlui/src/components/Button/Button.module.scss:10\n",
+		"    Also used by: IconButton\n",
+		"\n- padding-Button\n    Default: (none); dark: (none)\n",
+		"\n- gap-Button\n    Not documented on a reference page\n",
+	} {
+		if !strings.Contains(text, s) {
+			t.Fatalf("missing %q in:\n%s", s, text)
+		}
+	}
+
+	if text := call(map[string]interface{}{"name": "backgroundColor-Buton"}); text != "No theme variable named backgroundColor-Buton. Did you mean: backgroundColor-Button?\n" {
+		t.Fatalf("miss = %q", text)
+	}
+	if text := call(nil); !strings.Contains(text, "- Button (3)\n- IconButton (1)\nNote: 1 createThemeVar declaration(s) in the stylesheets") || strings.Contains(text, "theming") {
+		t.Fatalf("overview:\n%s", text)
+	}
+	text = call(map[string]interface{}{"name": "outlineColor-Button"})
+	if !strings.Contains(text, "Defined: xmlui/src/components/theming/_common.scss:1\n") || strings.Contains(text, "Used by") {
+		t.Fatalf("shared stylesheet lookup:\n%s", text)
+	}
+}
+
+// Case-insensitive lookups resolve through an index built in sorted order,
+// so names folding to one string always resolve the same way.
+func TestThemeVarRegistryFoldsNamesDeterministically(t *testing.T) {
+	source := &searchIndex{Files: []indexedFile{{
+		Path:  filepath.Join("/corpus", "xmlui", "src", "components", "button", "b.module.scss"),
+		Name:  "b.module.scss",
+		Lines: []string{`$a: createThemeVar("gap-button");`, `$b: createThemeVar("gap-Button");`},
+	}, {
+		Path:  filepath.Join("/corpus", "xmlui", "src", "components", "Button", "B.module.scss"),
+		Name:  "B.module.scss",
+		Lines: []string{`$c: createThemeVar("padding-Button");`},
+	}}}
+	var docs []documentedSchema
+	for _, name := range []string{"Button", "button"} {
+		docs = append(docs, documentedSchema{path: filepath.Join("/corpus", "docs", name+".md"), schema: &ComponentSchema{Component: name}})
+	}
+	registry := buildThemeVarRegistry("/corpus", docs, source)
+	if v := registry.lookup("GAP-BUTTON"); v == nil || v.Name != "gap-Button" {
+		t.Fatalf("lookup = %+v, want gap-Button", v)
+	}
+	if v := registry.lookup("gap-button"); v == nil || v.Name != "gap-button" {
+		t.Fatalf("exact lookup = %+v", v)
+	}
+	if c, vars := registry.component("BUTTON"); c != "Button" || len(vars) != 1 {
+		t.Fatalf("component = %s %d", c, len(vars))
+	}
+	if c, vars := registry.component("button"); c != "button" || len(vars) != 2 {
+		t.Fatalf("exact component = %s %d", c, len(vars))
+	}
+}
+
+// Registries are cached per corpus tag, so two corpora do not evict each
+// other.
+func TestThemeVarRegistryCachedPerCorpus(t *testing.T) {
+	first := setupThemeVarFixture(t)
+	second := setupThemeVarFixture(t)
+	a := themeVarRegistryFor(context.Background(), first)
+	b := themeVarRegistryFor(context.Background(), second)
+	if a == b {
+		t.Fatal("two corpora share one registry")
+	}
+	if again := themeVarRegistryFor(context.Background(), first); again != a {
+		t.Fatal("the first corpus's registry was rebuilt")
+	}
+}
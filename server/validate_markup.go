@@ -0,0 +1,506 @@
+package server
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Diagnostic severities. Unknown components are errors, and so are unknown
+// events on a component whose page (or its parent's) documents events; an
+// unknown prop, or an event where no page documents any, is a warning,
+// since a component may accept members its page does not list.
+const (
+	markupSeverityError   = "error"
+	markupSeverityWarning = "warning"
+)
+
+// maxMarkupSuggestions bounds each diagnostic's "did you mean" list.
+const maxMarkupSuggestions = 3
+
+// Reference pages the attribute allowlists are read from, found by file
+// name under Pages: every "### `name`" heading and every table row led by a
+// `name` cell is one attribute. A corpus without a page falls back to the
+// built-in list below, a snapshot of that page.
+const (
+	markupLayoutPropsPage = "layout-props.md"
+	markupCommonPropsPage = "common-props.md"
+)
+
+// markupCommonAttributes are accepted on every component.
+var markupCommonAttributes = []string{
+	"id", "when", "testId", "inspect", "uid",
+	"tooltip", "tooltipMarkdown", "tooltipOptions", "animation", "animationOptions",
+}
+
+// markupLayoutProperties are XMLUI's layout properties, which every visual
+// component accepts without listing them on its own page. Each may also
+// carry a breakpoint suffix, e.g. "width-md".
+var markupLayoutProperties = []string{
+	"width", "minWidth", "maxWidth", "height", "minHeight", "maxHeight",
+	"top", "right", "bottom", "left", "gap", "zIndex",
+	"padding", "paddingHorizontal", "paddingVertical", "paddingTop", "paddingRight", "paddingBottom", "paddingLeft",
+	"margin", "marginHorizontal", "marginVertical", "marginTop", "marginRight", "marginBottom", "marginLeft",
+	"border", "borderHorizontal", "borderVertical", "borderTop", "borderRight", "borderBottom", "borderLeft",
+	"borderColor", "borderStyle", "borderWidth", "borderRadius", "radius",
+	"radiusTopLeft", "radiusTopRight", "radiusBottomLeft", "radiusBottomRight",
+	"color", "backgroundColor", "background", "opacity", "shadow", "boxShadow", "cursor", "outline",
+	"fontFamily", "fontSize", "fontWeight", "fontStyle", "fontVariant", "lineHeight", "letterSpacing",
+	"textAlign", "textAlignLast", "textDecoration", "textDecorationLine", "textDecorationColor",
+	"textDecorationStyle", "textDecorationThickness", "textIndent", "textShadow", "textTransform",
+	"textUnderlineOffset", "userSelect", "whiteSpace", "wordBreak", "wordSpacing", "wordWrap",
+	"overflowX", "overflowY", "transform", "transition", "direction", "horizontalAlignment",
+	"verticalAlignment", "wrapContent", "canShrink", "zoom",
+}
+
+// markupAttributeHeadingRe and markupAttributeRowRe read one attribute
+// name from a reference page heading ("### `width` [#width]") or table
+// row ("| `width` | ... |").
+var (
+	markupAttributeHeadingRe = regexp.MustCompile("^#{2,4}\\s+`?([a-z][A-Za-z0-9]*)`?\\s*(?:\\[#[^\\]]*\\])?\\s*$")
+	markupAttributeRowRe     = regexp.MustCompile("^\\|\\s*`([a-z][A-Za-z0-9]*)`\\s*\\|")
+)
+
+// markupBreakpointRe strips a responsive breakpoint suffix from a layout
+// property name.
+var markupBreakpointRe = regexp.MustCompile(`-(xs|sm|md|lg|xl|xxl)$`)
+
+// markupDiagnostic is one problem the validator found, at a 1-based line
+// and rune column of the checked markup.
+type markupDiagnostic struct {
+	Line        int
+	Column      int
+	Severity    string
+	Message     string
+	Suggestions []string
+}
+
+// markupVocabulary is what markup is checked against: the documented
+// components, plus the user-defined ones ("<Component name=...>") in the
+// example roots and in the markup itself.
+type markupVocabulary struct {
+	components  map[string]*ComponentSchema
+	names       []string
+	userDefined map[string]bool
+	families    *componentFamilies
+
+	// common and layout are the attributes every component accepts; nil
+	// selects markupCommonAttributes and markupLayoutProperties.
+	common []string
+	layout []string
+}
+
+// markupVocabularyFor collects the component docs and extension docs
+// schemas, one per name in documentedComponentSchemas' precedence order,
+// the attribute allowlists from the Pages reference pages and the example
+// roots' user-defined components.
+func markupVocabularyFor(ctx context.Context, homeDir string, exampleRoots []string) *markupVocabulary {
+	vocab := &markupVocabulary{components: make(map[string]*ComponentSchema), userDefined: make(map[string]bool), families: componentFamiliesFor(ctx, homeDir)}
+	for _, doc := range documentedComponentSchemas(componentDocsIndexes(ctx, homeDir)) {
+		if _, seen := vocab.components[doc.schema.Component]; !seen {
+			vocab.components[doc.schema.Component] = doc.schema
+			vocab.names = append(vocab.names, doc.schema.Component)
+		}
+	}
+	paths := GetRepoPaths(homeDir)
+	if pages := searchIndexFor(ctx, homeDir, filepath.Join(homeDir, paths.Pages), docsIndexExtensions); pages != nil {
+		vocab.common = markupAttributeReference(pages, markupCommonPropsPage)
+		vocab.layout = markupAttributeReference(pages, markupLayoutPropsPage)
+	}
+	sort.Strings(vocab.names)
+
+	parent := commonParent(exampleRoots)
+	for _, root := range exampleRoots {
+		idx := searchIndexFor(ctx, parent, root, exampleIndexExtensions)
+		if idx == nil {
+			continue
+		}
+		for _, doc := range idx.markupDocs() {
+			vocab.addUserDefined(doc)
+		}
+	}
+	return vocab
+}
+
+// markupAttributeReference returns the attribute names the Pages page
+// called name lists, or nil when the index has no such page or it lists
+// none.
+func markupAttributeReference(pages *searchIndex, name string) []string {
+	var names []string
+	for i := range pages.Files {
+		file := &pages.Files[i]
+		if file.Name != name {
+			continue
+		}
+		inFence := false
+		for _, line := range file.Lines {
+			trimmed := strings.TrimSpace(line)
+			if strings.HasPrefix(trimmed, "```") {
+				inFence = !inFence
+			}
+			if inFence {
+				continue
+			}
+			m := markupAttributeHeadingRe.FindStringSubmatch(trimmed)
+			if m == nil {
+				m = markupAttributeRowRe.FindStringSubmatch(trimmed)
+			}
+			if m != nil && !containsString(names, m[1]) {
+				names = append(names, m[1])
+			}
+		}
+		break
+	}
+	return names
+}
+
+// commonAttributes returns the attributes every component accepts: the
+// reference pages' when read, the built-in lists otherwise.
+func (v *markupVocabulary) commonAttributes() (common, layout []string) {
+	common, layout = v.common, v.layout
+	if len(common) == 0 {
+		common = markupCommonAttributes
+	}
+	if len(layout) == 0 {
+		layout = markupLayoutProperties
+	}
+	return common, layout
+}
+
+// isCommonAttribute reports whether every component accepts name, with or
+// without a breakpoint suffix.
+func (v *markupVocabulary) isCommonAttribute(name string) bool {
+	base := markupBreakpointRe.ReplaceAllString(name, "")
+	common, layout := v.commonAttributes()
+	for _, list := range [][]string{common, layout} {
+		for _, known := range list {
+			if known == name || known == base {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (v *markupVocabulary) addUserDefined(doc *markupDoc) {
+	for _, el := range doc.Elements {
+		if el.Name == "Component" && el.Attrs["name"] != "" {
+			v.userDefined[el.Attrs["name"]] = true
+		}
+	}
+}
+
+// members returns the props and events a component documents, with those
+// of the component it derives from (see componentFamilies). ok is false
+// when neither page lists any, so nothing can be checked.
+func (v *markupVocabulary) members(component string) (props, events map[string]bool, ok bool) {
+	props, events = make(map[string]bool), make(map[string]bool)
+	parent := ""
+	if v.families != nil {
+		parent = v.families.parent(component)
+	}
+	for _, name := range []string{component, parent} {
+		schema := v.components[name]
+		if schema == nil {
+			continue
+		}
+		for _, m := range schema.Props {
+			props[m.Name] = true
+		}
+		for _, m := range schema.Events {
+			events[m.Name] = true
+		}
+	}
+	return props, events, len(props) > 0 || len(events) > 0
+}
+
+// validateMarkup checks src's elements and attributes against vocab.
+// Lowercase helper tags (variable, property, event, script, ...) are not
+// components; "property" and "event" are checked by their name attribute
+// against the enclosing component. Attributes with a "." or ":" (var.x,
+// namespaces) and user-defined components' attributes are not checked.
+func validateMarkup(src string, vocab *markupVocabulary) []markupDiagnostic {
+	doc := parseMarkup(strings.Split(src, "\n"))
+	vocab.addUserDefined(doc)
+
+	var diags []markupDiagnostic
+	report := func(offset int, severity, message string, suggestions []string) {
+		line, col := markupPosition(src, offset)
+		diags = append(diags, markupDiagnostic{Line: line, Column: col, Severity: severity, Message: message, Suggestions: suggestions})
+	}
+	for _, el := range doc.Elements {
+		// Namespaced elements (extension packages) are checked by their
+		// local name when it is documented, and skipped otherwise.
+		if i := strings.LastIndexByte(el.Name, ':'); i >= 0 && vocab.components[el.Name[i+1:]] != nil {
+			el = &markupElement{Name: el.Name[i+1:], Attrs: el.Attrs, Parent: el.Parent, Offset: el.Offset, TagEnd: el.TagEnd}
+		}
+		if el.Name == "" || !unicode.IsUpper(rune(el.Name[0])) {
+			if (el.Name == "property" || el.Name == "event") && el.Parent != nil {
+				validateHelperTag(src, el, vocab, report)
+			}
+			continue
+		}
+		if el.Name == "Component" || vocab.userDefined[el.Name] {
+			continue
+		}
+		if _, ok := vocab.components[el.Name]; !ok && !strings.Contains(el.Name, ":") {
+			report(el.Offset+1, markupSeverityError, fmt.Sprintf("unknown component <%s>", el.Name), closestNames(el.Name, vocab.names))
+			continue
+		}
+		props, events, ok := vocab.members(el.Name)
+		if !ok {
+			continue
+		}
+		for _, name := range sortedKeys(el.Attrs) {
+			if strings.ContainsAny(name, ".:") || name == "xmlns" || props[name] || vocab.isCommonAttribute(name) {
+				continue
+			}
+			offset := el.Offset + markupAttributeOffset(src[el.Offset:el.TagEnd], name)
+			if event, isHandler := eventForHandler(name); isHandler {
+				if !events[event] {
+					report(offset, unknownEventSeverity(events), fmt.Sprintf("unknown event %q on <%s> (handler %s)", event, el.Name, name), handlerNames(closestNames(event, sortedKeys(events))))
+				}
+				continue
+			}
+			_, layout := vocab.commonAttributes()
+			report(offset, markupSeverityWarning, fmt.Sprintf("unknown prop %q on <%s>", name, el.Name), closestNames(name, append(sortedKeys(props), layout...)))
+		}
+	}
+	if doc.Err != nil {
+		line := len(strings.Split(src, "\n"))
+		var syntaxErr *xml.SyntaxError
+		msg := doc.Err.Error()
+		if errors.As(doc.Err, &syntaxErr) {
+			line, msg = syntaxErr.Line, syntaxErr.Msg
+		}
+		diags = append(diags, markupDiagnostic{Line: line, Column: 1, Severity: markupSeverityError, Message: "markup is not well-formed: " + msg})
+	}
+	sort.SliceStable(diags, func(i, j int) bool {
+		if diags[i].Line != diags[j].Line {
+			return diags[i].Line < diags[j].Line
+		}
+		return diags[i].Column < diags[j].Column
+	})
+	return diags
+}
+
+// validateHelperTag checks a <property name="..."> or <event name="...">
+// child against its enclosing component's props or events.
+func validateHelperTag(src string, el *markupElement, vocab *markupVocabulary, report func(int, string, string, []string)) {
+	name, owner := el.Attrs["name"], el.Parent.Name
+	if name == "" || vocab.components[owner] == nil {
+		return
+	}
+	props, events, ok := vocab.members(owner)
+	if !ok {
+		return
+	}
+	offset := el.Offset + markupAttributeOffset(src[el.Offset:el.TagEnd], "name")
+	if el.Name == "event" && !events[name] {
+		report(offset, unknownEventSeverity(events), fmt.Sprintf("unknown event %q on <%s>", name, owner), closestNames(name, sortedKeys(events)))
+	}
+	if el.Name == "property" && !props[name] && !vocab.isCommonAttribute(name) {
+		report(offset, markupSeverityWarning, fmt.Sprintf("unknown prop %q on <%s>", name, owner), closestNames(name, sortedKeys(props)))
+	}
+}
+
+// unknownEventSeverity grades an event missing from events, a component's
+// documented events: an error when there are some to check against, a
+// warning when its pages have no Events section at all.
+func unknownEventSeverity(events map[string]bool) string {
+	if len(events) == 0 {
+		return markupSeverityWarning
+	}
+	return markupSeverityError
+}
+
+// eventForHandler maps an event handler attribute to its event: "onClick"
+// to "click", "onDidChange" to "didChange".
+func eventForHandler(attr string) (string, bool) {
+	rest := strings.TrimPrefix(attr, "on")
+	if rest == attr || rest == "" || !unicode.IsUpper(rune(rest[0])) {
+		return "", false
+	}
+	return strings.ToLower(rest[:1]) + rest[1:], true
+}
+
+func handlerNames(events []string) []string {
+	for i, e := range events {
+		events[i] = "on" + strings.ToUpper(e[:1]) + e[1:]
+	}
+	return events
+}
+
+// closestNames returns up to maxMarkupSuggestions of candidates within a
+// case-insensitive edit distance of a third of name's length (at least 2),
+// nearest first.
+func closestNames(name string, candidates []string) []string {
+	limit := max(2, len(name)/3)
+	type scored struct {
+		name string
+		dist int
+	}
+	var matches []scored
+	seen := make(map[string]bool)
+	for _, c := range candidates {
+		if seen[c] {
+			continue
+		}
+		seen[c] = true
+		if d := levenshtein(strings.ToLower(name), strings.ToLower(c)); d <= limit {
+			matches = append(matches, scored{c, d})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].dist != matches[j].dist {
+			return matches[i].dist < matches[j].dist
+		}
+		return matches[i].name < matches[j].name
+	})
+	var out []string
+	for i := 0; i < len(matches) && i < maxMarkupSuggestions; i++ {
+		out = append(out, matches[i].name)
+	}
+	return out
+}
+
+// markupAttributeOffset finds attribute name in a start tag's source, as
+// a whole word after whitespace, or returns 1 (the element name) if it
+// cannot.
+func markupAttributeOffset(tag, name string) int {
+	for from := 0; from < len(tag); {
+		i := strings.Index(tag[from:], name)
+		if i < 0 {
+			break
+		}
+		i += from
+		end := i + len(name)
+		if i > 0 && strings.IndexByte(" \t\n\r\f", tag[i-1]) >= 0 && (end == len(tag) || strings.IndexByte(" \t\n\r\f=/>", tag[end]) >= 0) {
+			return i
+		}
+		from = i + 1
+	}
+	return 1
+}
+
+// markupPosition converts a byte offset in src to a 1-based line and rune
+// column.
+func markupPosition(src string, offset int) (line, col int) {
+	offset = min(offset, len(src))
+	before := src[:offset]
+	lineStart := strings.LastIndexByte(before, '\n') + 1
+	return strings.Count(before, "\n") + 1, utf8.RuneCountInString(before[lineStart:]) + 1
+}
+
+func NewValidateMarkupTool(homeDir string, exampleRoots []string) (mcp.Tool, func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)) {
+	tool := mcp.NewTool("xmlui_validate_markup",
+		mcp.WithDescription("Checks XMLUI markup against the component docs before you show it to the user: reports unknown components, unknown props and unknown events (onX handlers and <event> tags) with line:column diagnostics and spelling suggestions. Pass either 'markup' (a fragment or whole file) or 'path' (an .xmlui file in an example root)."),
+		mcp.WithString("markup",
+			mcp.Description("XMLUI markup to check, e.g. '<VStack><Buton label=\"Save\" onClick=\"save()\" /></VStack>'"),
+		),
+		mcp.WithString("path",
+			mcp.Description("An .xmlui file in an example root, as xmlui_examples reports it, instead of 'markup'"),
+		),
+	)
+
+	tool.Annotations = mcp.ToolAnnotation{
+		ReadOnlyHint:    true,
+		DestructiveHint: false,
+		IdempotentHint:  true,
+		OpenWorldHint:   false,
+	}
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		markup, _ := req.Params.Arguments["markup"].(string)
+		relPath, _ := req.Params.Arguments["path"].(string)
+		relPath = strings.TrimSpace(relPath)
+		source := "markup"
+		switch {
+		case strings.TrimSpace(markup) != "" && relPath != "":
+			return mcp.NewToolResultError("Pass either 'markup' or 'path', not both"), nil
+		case relPath != "":
+			content, rel, err := readExampleMarkup(exampleRoots, relPath)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			markup, source = content, rel
+		case strings.TrimSpace(markup) == "":
+			return mcp.NewToolResultError("Missing 'markup' or 'path' parameter"), nil
+		}
+
+		vocab := markupVocabularyFor(ctx, homeDir, exampleRoots)
+		if ctx.Err() != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Validation %s before the component docs were indexed", searchTruncation(ctx))), nil
+		}
+		if len(vocab.components) == 0 {
+			return mcp.NewToolResultError(fmt.Sprintf("No component docs found under %s to validate against", GetRepoPaths(homeDir).ComponentDocs)), nil
+		}
+		return mcp.NewToolResultText(formatMarkupDiagnostics(source, validateMarkup(markup, vocab), len(vocab.components))), nil
+	}
+
+	return tool, handler
+}
+
+// readExampleMarkup reads an .xmlui file given relative to the example
+// roots' common parent (as xmlui_examples reports paths) or absolute, as
+// long as it lies in an example root. It returns the file's content and
+// its path relative to that parent.
+func readExampleMarkup(exampleRoots []string, relPath string) (string, string, error) {
+	if filepath.Ext(relPath) != ".xmlui" {
+		return "", "", fmt.Errorf("Invalid 'path' parameter %q: only .xmlui files are supported", relPath)
+	}
+	parent := commonParent(exampleRoots)
+	fullPath := relPath
+	if !filepath.IsAbs(fullPath) {
+		fullPath = filepath.Join(parent, relPath)
+	}
+	rel := toRepoRelative(parent, fullPath)
+	allowed := false
+	for _, root := range exampleRoots {
+		if isWithinDir(root, fullPath) {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return "", "", fmt.Errorf("Invalid 'path' parameter %q: not in an example root", relPath)
+	}
+	content, err := os.ReadFile(fullPath)
+	if err != nil {
+		return "", "", fmt.Errorf("Failed to read %s: %v", rel, errWithoutPath(err))
+	}
+	return string(content), rel, nil
+}
+
+// formatMarkupDiagnostics renders diagnostics one per line, compiler
+// style: "source:line:col: severity: message (did you mean ...?)".
+func formatMarkupDiagnostics(source string, diags []markupDiagnostic, documented int) string {
+	var out strings.Builder
+	errorCount := 0
+	for _, d := range diags {
+		if d.Severity == markupSeverityError {
+			errorCount++
+		}
+		fmt.Fprintf(&out, "%s:%d:%d: %s: %s", source, d.Line, d.Column, d.Severity, d.Message)
+		if len(d.Suggestions) > 0 {
+			fmt.Fprintf(&out, " (did you mean %s?)", strings.Join(d.Suggestions, ", "))
+		}
+		out.WriteString("\n")
+	}
+	if len(diags) == 0 {
+		fmt.Fprintf(&out, "No problems found (checked against %d documented components).\n", documented)
+	} else {
+		fmt.Fprintf(&out, "\nProblems: %d (errors=%d, warnings=%d; checked against %d documented components)\n", len(diags), errorCount, len(diags)-errorCount, documented)
+	}
+	return out.String()
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestValidateMarkupReportsUnknownNames(t *testing.T) {
+	vocab := &markupVocabulary{
+		components: map[string]*ComponentSchema{
+			"Button": parseComponentSchema("Button", strings.Split(schemaButtonDoc, "\n")),
+			"Stack":  parseComponentSchema("Stack", []string{"# Stack", "## Properties", "### `orientation`"}),
+			"VStack": parseComponentSchema("VStack", []string{"# VStack"}),
+		},
+		names:       []string{"Button", "Stack", "VStack"},
+		userDefined: map[string]bool{},
+		families:    &componentFamilies{parents: map[string]string{"VStack": "Stack"}},
+	}
+	src := `<VStack orientation="vertical" width-md="50%" gapp="2" onScroll="track()">
+  <Buton label="Save" />
+  <Button sise="xs" onClick="save()" onClik="save()" var.count="{0}">
+    <event name="clik">save()</event>
+  </Button>
+  <Component name="Card"><Text /></Component>
+  <Card title="x" />
+</VStack>`
+	var got []string
+	for _, d := range validateMarkup(src, vocab) {
+		got = append(got, fmt.Sprintf("%d:%d | %s | %s | %s", d.Line, d.Column, d.Severity, d.Message, strings.Join(d.Suggestions, ",")))
+	}
+	want := []string{
+		"1:47 | warning | unknown prop \"gapp\" on <VStack> | gap",
+		"1:56 | warning | unknown event \"scroll\" on <VStack> (handler onScroll) | ",
+		"2:4 | error | unknown component <Buton> | Button",
+		"3:11 | warning | unknown prop \"sise\" on <Button> | size",
+		"3:38 | error | unknown event \"clik\" on <Button> (handler onClik) | onClick",
+		"4:12 | error | unknown event \"clik\" on <Button> | click",
+		"6:27 | error | unknown component <Text> | ",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("diagnostics:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestValidateMarkupToolReadsExampleFiles(t *testing.T) {
+	ResetRepoPaths()
+	t.Cleanup(ResetRepoPaths)
+	home := t.TempDir()
+	componentsDir := filepath.Join(home, "docs", "content", "components")
+	if err := os.MkdirAll(componentsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(componentsDir, "Button.md"), []byte(schemaButtonDoc), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	examples := t.TempDir()
+	if err := os.WriteFile(filepath.Join(examples, "Main.xmlui"), []byte("<Button enabled=\"false\" onClick=\"go()\" />\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, handler := NewValidateMarkupTool(home, []string{examples})
+
+	var req mcp.CallToolRequest
+	req.Params.Arguments = map[string]interface{}{"path": filepath.Join(examples, "Main.xmlui")}
+	result, err := handler(context.Background(), req)
+	if err != nil || result.IsError {
+		t.Fatalf("unexpected error: %v %+v", err, result)
+	}
+	if text := result.Content[0].(mcp.TextContent).Text; text != "No problems found (checked against 1 documented components).\n" {
+		t.Fatalf("text = %q", text)
+	}
+
+	req.Params.Arguments = map[string]interface{}{"path": "Main.xmlui"}
+	result, _ = handler(context.Background(), req)
+	if text := result.Content[0].(mcp.TextContent).Text; !strings.HasPrefix(text, "No problems") {
+		t.Fatalf("relative path: %q", text)
+	}
+
+	req.Params.Arguments = map[string]interface{}{"markup": "<Butn />"}
+	result, _ = handler(context.Background(), req)
+	if text := result.Content[0].(mcp.TextContent).Text; !strings.HasPrefix(text, "markup:1:2: error: unknown component <Butn> (did you mean Button?)\n") {
+		t.Fatalf("text = %q", text)
+	}
+
+	req.Params.Arguments = map[string]interface{}{"path": "../outside.xmlui"}
+	if result, _ := handler(context.Background(), req); !result.IsError {
+		t.Fatal("a path outside the example roots was read")
+	}
+}
+
+// The common and layout allowlists come from the Pages reference pages
+// when the corpus has them, so a name they drop is flagged.
+func TestValidateMarkupReadsAttributeReferencePages(t *testing.T) {
+	ResetRepoPaths()
+	t.Cleanup(ResetRepoPaths)
+	home := t.TempDir()
+	componentsDir := filepath.Join(home, "docs", "content", "components")
+	stylesDir := filepath.Join(home, "docs", "content", "pages", "styles-and-themes")
+	for _, dir := range []string{componentsDir, stylesDir} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		filepath.Join(componentsDir, "Button.md"):                              schemaButtonDoc,
+		filepath.Join(stylesDir, markupLayoutPropsPage):                        "# Layout Properties\n\n## Dimensions\n\n### `width` [#width]\n\nThe width.\n\n| Name | Description |\n| --- | --- |\n| `gap` | Space between children |\n",
+		filepath.Join(home, "docs", "content", "pages", markupCommonPropsPage): "# Common Properties\n\n### `id`\n\nIdentifies the component.\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	vocab := markupVocabularyFor(context.Background(), home, nil)
+	src := `<Button id="b" width="1" gap-md="2" zoom="3" testId="x" />`
+	var got []string
+	for _, d := range validateMarkup(src, vocab) {
+		got = append(got, fmt.Sprintf("%d:%d %s", d.Line, d.Column, d.Message))
+	}
+	want := []string{`1:37 unknown prop "zoom" on <Button>`, `1:46 unknown prop "testId" on <Button>`}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("diagnostics:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestMarkupAttributeOffsetMatchesWholeNames(t *testing.T) {
+	tag := `<Stack gapp="1" data-gap="2" gap="3"`
+	if got := markupAttributeOffset(tag, "gap"); got != strings.Index(tag, ` gap=`)+1 {
+		t.Fatalf("offset = %d", got)
+	}
+	if got := markupAttributeOffset(tag, "width"); got != 1 {
+		t.Fatalf("missing attribute offset = %d", got)
+	}
+}